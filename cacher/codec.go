@@ -0,0 +1,91 @@
+package cacher
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+
+	"github.com/vmihailenco/msgpack"
+)
+
+// Codec interface to serialize values stored in Cacher
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	// ErrUnknownCodec issued when CodecByName receives unknown codec name
+	ErrUnknownCodec = errors.New("unknown codec")
+
+	// Raw codec stores primitive types and encoding.BinaryMarshaler
+	// exactly as go-redis does, used by default
+	Raw Codec = rawCodec{}
+
+	// JSON codec uses encoding/json
+	JSON Codec = jsonCodec{}
+
+	// Msgpack codec uses github.com/vmihailenco/msgpack
+	Msgpack Codec = msgpackCodec{}
+
+	// Gob codec uses encoding/gob
+	Gob Codec = gobCodec{}
+)
+
+// CodecByName returns codec by its name (raw, json, msgpack or gob),
+// empty name returns Raw codec
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "", "raw":
+		return Raw, nil
+	case "json":
+		return JSON, nil
+	case "msgpack":
+		return Msgpack, nil
+	case "gob":
+		return Gob, nil
+	default:
+		return nil, ErrUnknownCodec
+	}
+}
+
+type jsonCodec struct{}
+
+// Marshal value to JSON
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal value from JSON
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type msgpackCodec struct{}
+
+// Marshal value to msgpack
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal value from msgpack
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+// Marshal value to gob
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal value from gob
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
@@ -0,0 +1,88 @@
+package cacher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testStruct struct {
+	Name    string
+	Count   int
+	Tags    []string
+	Created time.Time
+}
+
+func TestCodecs(t *testing.T) {
+	src := testStruct{
+		Name:    "name",
+		Count:   42,
+		Tags:    []string{"a", "b"},
+		Created: time.Date(2018, 4, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	for _, name := range []string{"json", "msgpack", "gob"} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := CodecByName(name)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			data, err := codec.Marshal(src)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			var dst testStruct
+			if err = codec.Unmarshal(data, &dst); !assert.NoError(t, err) {
+				t.FailNow()
+			}
+
+			assert.Equal(t, src.Name, dst.Name)
+			assert.Equal(t, src.Count, dst.Count)
+			assert.Equal(t, src.Tags, dst.Tags)
+			assert.True(t, src.Created.Equal(dst.Created))
+		})
+	}
+
+	t.Run("raw", func(t *testing.T) {
+		codec, err := CodecByName("")
+		if !assert.NoError(t, err) || !assert.Equal(t, Raw, codec) {
+			t.FailNow()
+		}
+
+		cases := []struct {
+			src  interface{}
+			dst  interface{}
+			data string
+		}{
+			{"string", new(string), "string"},
+			{[]byte("bytes"), new([]byte), "bytes"},
+			{int64(-42), new(int64), "-42"},
+			{uint8(42), new(uint8), "42"},
+			{1.5, new(float64), "1.5"},
+			{true, new(bool), "1"},
+			{false, new(bool), "0"},
+		}
+
+		for _, item := range cases {
+			data, err := codec.Marshal(item.src)
+			if !assert.NoError(t, err) || !assert.Equal(t, item.data, string(data)) {
+				t.FailNow()
+			}
+
+			if err = codec.Unmarshal(data, item.dst); !assert.NoError(t, err) {
+				t.FailNow()
+			}
+		}
+
+		_, err = codec.Marshal(testStruct{})
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := CodecByName("unknown")
+		assert.Equal(t, ErrUnknownCodec, err)
+	})
+}
@@ -0,0 +1,122 @@
+package cacher
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrNotAssignable issued when loaded value cannot be stored to val
+var ErrNotAssignable = errors.New("loaded value is not assignable to val")
+
+// Loader returns value to cache when key is missing
+type Loader func() (interface{}, error)
+
+type (
+	// flight of the loader, shared by concurrent callers of the key
+	flight struct {
+		wg  sync.WaitGroup
+		val interface{}
+		err error
+	}
+
+	flightKey struct {
+		cacher Cacher
+		key    string
+	}
+)
+
+var (
+	flightsMu sync.Mutex
+	flights   = make(map[flightKey]*flight)
+)
+
+// GetOrSet gets key to val, when key is missing loader is called
+// and its result is stored to val and cached with ttl.
+// Concurrent callers of the missing key in the process share one loader call.
+func GetOrSet(c Cacher, key string, val interface{}, ttl time.Duration, loader Loader) error {
+	found, err := c.Lookup(key, val)
+	if err != nil || found {
+		return err
+	}
+
+	loaded, err := load(c, key, ttl, loader)
+	if err != nil {
+		return err
+	}
+
+	return assign(val, loaded)
+}
+
+// load calls loader and caches its result, or waits for the call
+// already started for the key of the cacher
+func load(c Cacher, key string, ttl time.Duration, loader Loader) (interface{}, error) {
+	// cacher which cannot be a map key isn't deduplicated:
+	if !reflect.TypeOf(c).Comparable() {
+		return loadAndSet(c, key, ttl, loader)
+	}
+
+	fk := flightKey{cacher: c, key: key}
+
+	flightsMu.Lock()
+	if f, ok := flights[fk]; ok {
+		flightsMu.Unlock()
+		f.wg.Wait()
+		return f.val, f.err
+	}
+
+	f := new(flight)
+	f.wg.Add(1)
+	flights[fk] = f
+	flightsMu.Unlock()
+
+	defer func() {
+		flightsMu.Lock()
+		delete(flights, fk)
+		flightsMu.Unlock()
+
+		f.wg.Done()
+	}()
+
+	f.val, f.err = loadAndSet(c, key, ttl, loader)
+
+	return f.val, f.err
+}
+
+// loadAndSet calls loader and caches its result with ttl
+func loadAndSet(c Cacher, key string, ttl time.Duration, loader Loader) (interface{}, error) {
+	loaded, err := loader()
+	if err != nil {
+		return nil, err
+	}
+
+	return loaded, c.Set(key, loaded, ttl)
+}
+
+// assign src value to dst pointer, pointer src is dereferenced if needed
+func assign(dst, src interface{}) error {
+	d := reflect.ValueOf(dst)
+	if d.Kind() != reflect.Ptr || d.IsNil() {
+		return ErrNotAssignable
+	}
+	d = d.Elem()
+
+	s := reflect.ValueOf(src)
+	if !s.IsValid() {
+		d.Set(reflect.Zero(d.Type()))
+		return nil
+	}
+
+	if s.Kind() == reflect.Ptr && !s.Type().AssignableTo(d.Type()) && !s.IsNil() {
+		s = s.Elem()
+	}
+
+	if !s.Type().AssignableTo(d.Type()) {
+		return ErrNotAssignable
+	}
+
+	d.Set(s)
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package cacher_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/cacher"
+	"github.com/cryptopay-dev/yaga/cacher/memory"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+func TestGetOrSet(t *testing.T) {
+	var (
+		c     = memory.New()
+		key   = "test:get-or-set"
+		calls int
+	)
+
+	loader := func() (interface{}, error) {
+		calls++
+		return "value", nil
+	}
+
+	t.Run("should load missing value", func(t *testing.T) {
+		var val string
+		if err := cacher.GetOrSet(c, key, &val, time.Minute, loader); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		assert.Equal(t, "value", val)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should not load existing value", func(t *testing.T) {
+		var val string
+		if err := cacher.GetOrSet(c, key, &val, time.Minute, loader); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		assert.Equal(t, "value", val)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("should load empty value once", func(t *testing.T) {
+		var (
+			val   = 42
+			empty = func() (interface{}, error) {
+				calls++
+				return 0, nil
+			}
+		)
+
+		for i := 0; i < 2; i++ {
+			if err := cacher.GetOrSet(c, key+":empty", &val, time.Minute, empty); !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, 0, val)
+		}
+
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("should return loader error", func(t *testing.T) {
+		var (
+			val    string
+			errBad = errors.New("bad")
+		)
+
+		err := cacher.GetOrSet(c, key+":error", &val, time.Minute, func() (interface{}, error) {
+			return nil, errBad
+		})
+		assert.Equal(t, errBad, err)
+
+		found, err := c.Lookup(key+":error", &val)
+		assert.NoError(t, err)
+		assert.False(t, found)
+	})
+
+	t.Run("should fail on wrong type", func(t *testing.T) {
+		var val int
+		err := cacher.GetOrSet(c, key+":wrong", &val, time.Minute, func() (interface{}, error) {
+			return "string", nil
+		})
+		assert.Equal(t, cacher.ErrNotAssignable, err)
+	})
+
+	t.Run("should load value once for concurrent callers", func(t *testing.T) {
+		var (
+			wg      sync.WaitGroup
+			loads   = atomic.NewInt32(0)
+			release = make(chan struct{})
+		)
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				var val string
+				err := cacher.GetOrSet(c, key+":concurrent", &val, time.Minute, func() (interface{}, error) {
+					loads.Inc()
+					<-release
+					return "value", nil
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, "value", val)
+			}()
+		}
+
+		// let callers miss the key and wait for the loader:
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), loads.Load())
+	})
+}
@@ -1,14 +1,51 @@
 package cacher
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Cacher interface to abstract Redis/other
 type Cacher interface {
+	// Get key to val, val stays untouched when key is missing
 	Get(key string, val interface{}) error
+	// Lookup key to val and report whether key was found
+	Lookup(key string, val interface{}) (found bool, err error)
 	Set(key string, val interface{}, timeout time.Duration) error
+	// MGet keys to vals (map of key to pointer) and return keys which are missing
+	MGet(vals map[string]interface{}) (missing []string, err error)
+	// MSet vals (map of key to value) with the same timeout
+	MSet(vals map[string]interface{}, timeout time.Duration) error
+	// Keys by pattern, uses cursor-based iteration and doesn't block Redis
 	Keys(pattern string) ([]string, error)
+	// Scan iterates over keys by pattern, count is a hint of batch size
+	Scan(pattern string, count int64) Iterator
+	// DelPattern removes keys by pattern in batches
+	DelPattern(pattern string) error
 	TTL(key string) (time.Duration, error)
 	Expire(key string, duration time.Duration) error
-	Del(key string) error
+	Del(keys ...string) error
 	Exists(keys ...string) (int64, error)
+	// WithContext returns Cacher, which operations are bound to ctx
+	// and return ctx.Err() when ctx is done
+	WithContext(ctx context.Context) Cacher
+}
+
+// Iterator over keys returned by Cacher.Scan
+type Iterator interface {
+	// Next advances iterator, returns false when iteration
+	// is finished or error occurred
+	Next() bool
+	// Val returns current key
+	Val() string
+	// Err returns iteration error
+	Err() error
+}
+
+// Tagger is implemented by Cacher, which supports tag-based invalidation
+type Tagger interface {
+	// SetWithTags sets key and adds it to every tag
+	SetWithTags(key string, val interface{}, timeout time.Duration, tags ...string) error
+	// InvalidateTags removes all keys of tags
+	InvalidateTags(tags ...string) error
 }
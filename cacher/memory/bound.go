@@ -0,0 +1,110 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/cacher"
+)
+
+// WithContext returns memory-cache bound to ctx
+func (b *bound) WithContext(ctx context.Context) cacher.Cacher {
+	return b.service.WithContext(ctx)
+}
+
+// Get key from memory-cache to val interface, unless ctx is done
+func (b *bound) Get(key string, val interface{}) error {
+	if err := b.ctx.Err(); err != nil {
+		return err
+	}
+	return b.service.Get(key, val)
+}
+
+// Lookup key from memory-cache to val interface, unless ctx is done
+func (b *bound) Lookup(key string, val interface{}) (bool, error) {
+	if err := b.ctx.Err(); err != nil {
+		return false, err
+	}
+	return b.service.Lookup(key, val)
+}
+
+// Set key to memory-cache, unless ctx is done
+func (b *bound) Set(key string, val interface{}, expiration time.Duration) error {
+	if err := b.ctx.Err(); err != nil {
+		return err
+	}
+	return b.service.Set(key, val, expiration)
+}
+
+// MGet keys from memory-cache to vals, unless ctx is done
+func (b *bound) MGet(vals map[string]interface{}) ([]string, error) {
+	if err := b.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return b.service.MGet(vals)
+}
+
+// MSet vals to memory-cache, unless ctx is done
+func (b *bound) MSet(vals map[string]interface{}, expiration time.Duration) error {
+	if err := b.ctx.Err(); err != nil {
+		return err
+	}
+	return b.service.MSet(vals, expiration)
+}
+
+// Del keys from memory-cache, unless ctx is done
+func (b *bound) Del(keys ...string) error {
+	if err := b.ctx.Err(); err != nil {
+		return err
+	}
+	return b.service.Del(keys...)
+}
+
+// Exists check keys in memory-cache, unless ctx is done
+func (b *bound) Exists(keys ...string) (int64, error) {
+	if err := b.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return b.service.Exists(keys...)
+}
+
+// Keys fetch from memory-cache by pattern, unless ctx is done
+func (b *bound) Keys(pattern string) ([]string, error) {
+	if err := b.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return b.service.Keys(pattern)
+}
+
+// Scan iterates over snapshot of memory-cache keys by pattern,
+// iterator is empty and returns ctx.Err() when ctx is done
+func (b *bound) Scan(pattern string, count int64) cacher.Iterator {
+	if err := b.ctx.Err(); err != nil {
+		return &iterator{pos: -1, err: err}
+	}
+	return b.service.Scan(pattern, count)
+}
+
+// DelPattern removes keys by pattern from memory-cache, unless ctx is done
+func (b *bound) DelPattern(pattern string) error {
+	if err := b.ctx.Err(); err != nil {
+		return err
+	}
+	return b.service.DelPattern(pattern)
+}
+
+// TTL fetch for key from memory-cache, unless ctx is done
+func (b *bound) TTL(key string) (time.Duration, error) {
+	if err := b.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return b.service.TTL(key)
+}
+
+// Expire sets for key in memory-cache, unless ctx is done
+func (b *bound) Expire(key string, duration time.Duration) error {
+	if err := b.ctx.Err(); err != nil {
+		return err
+	}
+	return b.service.Expire(key, duration)
+}
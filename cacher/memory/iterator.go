@@ -0,0 +1,30 @@
+package memory
+
+// iterator over snapshot of keys
+type iterator struct {
+	keys []string
+	pos  int
+	err  error
+}
+
+// Next advances iterator
+func (i *iterator) Next() bool {
+	if i.pos+1 >= len(i.keys) {
+		return false
+	}
+	i.pos++
+	return true
+}
+
+// Val returns current key
+func (i *iterator) Val() string {
+	if i.pos < 0 || i.pos >= len(i.keys) {
+		return ""
+	}
+	return i.keys[i.pos]
+}
+
+// Err returns error of context, which iterator was created with
+func (i *iterator) Err() error {
+	return i.err
+}
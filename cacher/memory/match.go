@@ -0,0 +1,95 @@
+package memory
+
+// match reports whether key matches the Redis glob-style pattern.
+// Supported:
+//   - `*` matches any sequence of characters
+//   - `?` matches exactly one character
+//   - `[abc]`, `[^abc]` and `[a-z]` match character classes
+//   - `\` escapes the following character
+func match(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if match(pattern[1:], key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+			key = key[1:]
+		case '[':
+			if len(key) == 0 {
+				return false
+			}
+
+			var (
+				not     bool
+				matched bool
+			)
+
+			pattern = pattern[1:]
+			if len(pattern) > 0 && pattern[0] == '^' {
+				not = true
+				pattern = pattern[1:]
+			}
+
+			for len(pattern) > 0 && pattern[0] != ']' {
+				switch {
+				case pattern[0] == '\\' && len(pattern) >= 2:
+					pattern = pattern[1:]
+					if pattern[0] == key[0] {
+						matched = true
+					}
+				case len(pattern) >= 3 && pattern[1] == '-':
+					start, end := pattern[0], pattern[2]
+					if start > end {
+						start, end = end, start
+					}
+					if key[0] >= start && key[0] <= end {
+						matched = true
+					}
+					pattern = pattern[2:]
+				case pattern[0] == key[0]:
+					matched = true
+				}
+				pattern = pattern[1:]
+			}
+
+			if not {
+				matched = !matched
+			}
+			if !matched {
+				return false
+			}
+			key = key[1:]
+
+			// unterminated class matches till the end of pattern
+			if len(pattern) == 0 {
+				return len(key) == 0
+			}
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || pattern[0] != key[0] {
+				return false
+			}
+			key = key[1:]
+		}
+		pattern = pattern[1:]
+	}
+
+	return len(key) == 0
+}
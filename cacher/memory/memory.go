@@ -0,0 +1,332 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/cacher"
+)
+
+// ErrInvalidExpire issued when Set receives expiration less than a millisecond
+var ErrInvalidExpire = errors.New("memory: invalid expire time in set")
+
+type item struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// expired checks that item is outdated at the given moment
+func (i item) expired(now time.Time) bool {
+	return !i.expiresAt.IsZero() && !now.Before(i.expiresAt)
+}
+
+type store struct {
+	codec cacher.Codec
+
+	mu    sync.RWMutex
+	items map[string]item
+}
+
+// service wraps store, so janitor can be stopped
+// by finalizer when service is no longer reachable
+type service struct {
+	*store
+}
+
+// bound is memory-cache bound to context, it keeps service reachable
+type bound struct {
+	*service
+	ctx context.Context
+}
+
+// New creates memory-cache instance
+func New(opts ...Option) cacher.Cacher {
+	var (
+		options = newOptions(opts...)
+		s       = &store{codec: options.Codec, items: make(map[string]item)}
+		c       = &service{store: s}
+	)
+
+	if options.CleanupInterval > 0 {
+		stop := make(chan struct{})
+		go s.janitor(options.CleanupInterval, stop)
+		runtime.SetFinalizer(c, func(*service) { close(stop) })
+	}
+
+	return c
+}
+
+// WithContext returns memory-cache bound to ctx, its operations
+// never block, so they return ctx.Err() when ctx is done before them
+func (c *service) WithContext(ctx context.Context) cacher.Cacher {
+	if ctx == nil {
+		panic("nil context")
+	}
+	return &bound{service: c, ctx: ctx}
+}
+
+// janitor evicts expired keys until stop-channel will be closed
+func (s *store) janitor(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.evict()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// evict removes all expired keys
+func (s *store) evict() {
+	now := time.Now()
+
+	s.mu.Lock()
+	for key, it := range s.items {
+		if it.expired(now) {
+			delete(s.items, key)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// lookup returns not expired item, must be called under lock
+func (s *store) lookup(key string, now time.Time) (item, bool) {
+	it, ok := s.items[key]
+	if !ok || it.expired(now) {
+		return item{}, false
+	}
+	return it, true
+}
+
+// Get key from memory-cache to val interface
+func (s *store) Get(key string, val interface{}) error {
+	_, err := s.Lookup(key, val)
+	return err
+}
+
+// Lookup key from memory-cache to val interface and report whether key was found
+func (s *store) Lookup(key string, val interface{}) (bool, error) {
+	s.mu.RLock()
+	it, ok := s.lookup(key, time.Now())
+	s.mu.RUnlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	return true, s.codec.Unmarshal(it.value, val)
+}
+
+// Set key to memory-cache with value, and expiration
+func (s *store) Set(key string, val interface{}, expiration time.Duration) error {
+	it, err := s.newItem(val, expiration)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.items[key] = it
+	s.mu.Unlock()
+
+	return nil
+}
+
+// newItem marshals value and calculates its expiration
+func (s *store) newItem(val interface{}, expiration time.Duration) (item, error) {
+	var (
+		err error
+		it  item
+	)
+
+	if it.value, err = s.codec.Marshal(val); err != nil {
+		return it, err
+	}
+
+	if expiration > 0 {
+		// same precision as go-redis uses for SET PX
+		if expiration = expiration / time.Millisecond * time.Millisecond; expiration == 0 {
+			return it, ErrInvalidExpire
+		}
+		it.expiresAt = time.Now().Add(expiration)
+	}
+
+	return it, nil
+}
+
+// MGet keys from memory-cache to vals (map of key to pointer),
+// returns sorted keys which are missing
+func (s *store) MGet(vals map[string]interface{}) ([]string, error) {
+	var (
+		missing []string
+		found   = make(map[string]item, len(vals))
+		now     = time.Now()
+	)
+
+	s.mu.RLock()
+	for key := range vals {
+		if it, ok := s.lookup(key, now); ok {
+			found[key] = it
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	s.mu.RUnlock()
+
+	for key, it := range found {
+		if err := s.codec.Unmarshal(it.value, vals[key]); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(missing)
+
+	return missing, nil
+}
+
+// MSet vals (map of key to value) to memory-cache with the same expiration
+func (s *store) MSet(vals map[string]interface{}, expiration time.Duration) error {
+	var items = make(map[string]item, len(vals))
+
+	for key, val := range vals {
+		it, err := s.newItem(val, expiration)
+		if err != nil {
+			return err
+		}
+		items[key] = it
+	}
+
+	s.mu.Lock()
+	for key, it := range items {
+		s.items[key] = it
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Del keys from memory-cache
+func (s *store) Del(keys ...string) error {
+	s.mu.Lock()
+	for _, key := range keys {
+		delete(s.items, key)
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Exists check keys in memory-cache
+func (s *store) Exists(keys ...string) (int64, error) {
+	var (
+		count int64
+		now   = time.Now()
+	)
+
+	s.mu.RLock()
+	for _, key := range keys {
+		if _, ok := s.lookup(key, now); ok {
+			count++
+		}
+	}
+	s.mu.RUnlock()
+
+	return count, nil
+}
+
+// Keys fetch from memory-cache by pattern
+func (s *store) Keys(pattern string) ([]string, error) {
+	var (
+		now    = time.Now()
+		result = make([]string, 0)
+	)
+
+	s.mu.RLock()
+	for key, it := range s.items {
+		if !it.expired(now) && match(pattern, key) {
+			result = append(result, key)
+		}
+	}
+	s.mu.RUnlock()
+
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// Scan iterates over snapshot of memory-cache keys by pattern,
+// count is ignored
+func (s *store) Scan(pattern string, count int64) cacher.Iterator {
+	keys, _ := s.Keys(pattern)
+	return &iterator{keys: keys, pos: -1}
+}
+
+// DelPattern removes keys by pattern from memory-cache
+func (s *store) DelPattern(pattern string) error {
+	var now = time.Now()
+
+	s.mu.Lock()
+	for key, it := range s.items {
+		if !it.expired(now) && match(pattern, key) {
+			delete(s.items, key)
+		}
+	}
+	s.mu.Unlock()
+
+	return nil
+}
+
+// TTL fetch for key from memory-cache,
+// returns -2s when key not exists and -1s when key has no expiration
+func (s *store) TTL(key string) (time.Duration, error) {
+	now := time.Now()
+
+	s.mu.RLock()
+	it, ok := s.lookup(key, now)
+	s.mu.RUnlock()
+
+	switch {
+	case !ok:
+		return -2 * time.Second, nil
+	case it.expiresAt.IsZero():
+		return -1 * time.Second, nil
+	}
+
+	// Redis rounds TTL to the nearest second
+	ttl := it.expiresAt.Sub(now) + time.Second/2
+
+	return ttl / time.Second * time.Second, nil
+}
+
+// Expire sets for key in memory-cache,
+// not positive duration removes the key
+func (s *store) Expire(key string, duration time.Duration) error {
+	// same precision as go-redis uses for EXPIRE
+	duration = duration / time.Second * time.Second
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	it, ok := s.lookup(key, now)
+	if !ok {
+		return nil
+	}
+
+	if duration <= 0 {
+		delete(s.items, key)
+		return nil
+	}
+
+	it.expiresAt = now.Add(duration)
+	s.items[key] = it
+
+	return nil
+}
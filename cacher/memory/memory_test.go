@@ -0,0 +1,387 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/cacher"
+	"github.com/stretchr/testify/assert"
+)
+
+const keyTpl = "test:key:%d"
+
+func TestService(t *testing.T) {
+	c := New()
+
+	var (
+		i    = 1
+		val  int
+		key  = fmt.Sprintf(keyTpl, 0)
+		ttl1 = 100 * time.Second
+		ttl3 = 105 * time.Second
+	)
+
+	t.Run("cacher.Set", func(t *testing.T) {
+		// Try to cache value:
+		if err := c.Set(key, i, ttl1); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// Try to cache unsupported value:
+		if err := c.Set(key+":bad", struct{}{}, ttl1); !assert.Error(t, err) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("cacher.Get", func(t *testing.T) {
+		// Try to get value from cache:
+		if err := c.Get(key, &val); !assert.NoError(t, err) || !assert.Equal(t, i, val) {
+			t.FailNow()
+		}
+
+		// Try to get missing value from cache:
+		var missing = 42
+		if err := c.Get(key+":missing", &missing); !assert.NoError(t, err) || !assert.Equal(t, 42, missing) {
+			t.FailNow()
+		}
+
+		// Try to get value into wrong type:
+		if err := c.Get(key, struct{}{}); !assert.Error(t, err) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("cacher.TTL", func(t *testing.T) {
+		// Try to get ttl key from cache:
+		if ttl2, err := c.TTL(key); !assert.NoError(t, err) || !assert.Equal(t, ttl1, ttl2) {
+			t.FailNow()
+		}
+
+		// Try to get ttl of missing key:
+		if ttl2, err := c.TTL(key + ":missing"); !assert.NoError(t, err) || !assert.Equal(t, -2*time.Second, ttl2) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("cacher.Expire", func(t *testing.T) {
+		// Try to set ttl key in cache:
+		if err := c.Expire(key, ttl3); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// Try to get ttl key from cache:
+		if ttl2, err := c.TTL(key); !assert.NoError(t, err) || !assert.Equal(t, ttl3, ttl2) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("cacher.Exists", func(t *testing.T) {
+		// Try to check exists key:
+		if count, err := c.Exists(key, key, key+":missing"); !assert.NoError(t, err) || !assert.Equal(t, int64(2), count) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("cacher.Keys", func(t *testing.T) {
+		// Try to get keys from cache:
+		if keys, err := c.Keys(key); !assert.NoError(t, err) || !assert.Equal(t, []string{key}, keys) {
+			t.FailNow()
+		}
+
+		// Try to get keys by pattern:
+		if keys, err := c.Keys("test:*"); !assert.NoError(t, err) || !assert.Equal(t, []string{key}, keys) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("cacher.Del", func(t *testing.T) {
+		// Try to remove key from cache:
+		if err := c.Del(key); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// Try to check exists key:
+		if count, err := c.Exists(key); !assert.NoError(t, err) || !assert.True(t, count == 0) {
+			t.FailNow()
+		}
+	})
+}
+
+func TestExpiration(t *testing.T) {
+	c := New(CleanupInterval(time.Millisecond))
+	key := fmt.Sprintf(keyTpl, 1)
+
+	t.Run("key should expire", func(t *testing.T) {
+		if err := c.Set(key, "value", 10*time.Millisecond); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		time.Sleep(20 * time.Millisecond)
+
+		var val = "untouched"
+		if err := c.Get(key, &val); !assert.NoError(t, err) || !assert.Equal(t, "untouched", val) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("key should be evicted in background", func(t *testing.T) {
+		if err := c.Set(key, "value", 10*time.Millisecond); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		time.Sleep(20 * time.Millisecond)
+
+		s := c.(*service)
+		s.mu.RLock()
+		_, found := s.items[key]
+		s.mu.RUnlock()
+
+		assert.False(t, found)
+	})
+
+	t.Run("key without expiration", func(t *testing.T) {
+		if err := c.Set(key, "value", 0); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if ttl, err := c.TTL(key); !assert.NoError(t, err) || !assert.Equal(t, -time.Second, ttl) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("not positive expire should remove key", func(t *testing.T) {
+		if err := c.Expire(key, time.Millisecond*500); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if count, err := c.Exists(key); !assert.NoError(t, err) || !assert.Equal(t, int64(0), count) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("sub-millisecond expiration is invalid", func(t *testing.T) {
+		assert.Equal(t, ErrInvalidExpire, c.Set(key, "value", time.Microsecond))
+	})
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		key     string
+		result  bool
+	}{
+		{"*", "", true},
+		{"*", "any:key", true},
+		{"user:*", "user:1", true},
+		{"user:*", "users:1", false},
+		{"*:name", "user:1:name", true},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-b]llo", "hbllo", true},
+		{"h[a-b]llo", "hcllo", false},
+		{`h\*llo`, "h*llo", true},
+		{`h\*llo`, "hello", false},
+		{"a/b/*", "a/b/c/d", true},
+	}
+
+	for _, item := range cases {
+		assert.Equal(t, item.result, match(item.pattern, item.key), "%q ~ %q", item.pattern, item.key)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	type item struct {
+		Name  string
+		Count int
+	}
+
+	var (
+		key = fmt.Sprintf(keyTpl, 2)
+		src = item{Name: "name", Count: 42}
+		c   = New(Codec(cacher.Gob))
+	)
+
+	// Try to cache struct:
+	if err := c.Set(key, src, time.Minute); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// Try to get struct from cache:
+	var dst item
+	if err := c.Get(key, &dst); !assert.NoError(t, err) || !assert.Equal(t, src, dst) {
+		t.FailNow()
+	}
+}
+
+func TestLookup(t *testing.T) {
+	var (
+		c   = New()
+		key = fmt.Sprintf(keyTpl, 3)
+		val int
+	)
+
+	// Try to lookup missing key:
+	if found, err := c.Lookup(key, &val); !assert.NoError(t, err) || !assert.False(t, found) {
+		t.FailNow()
+	}
+
+	// Try to lookup empty value:
+	if err := c.Set(key, 0, time.Minute); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if found, err := c.Lookup(key, &val); !assert.NoError(t, err) || !assert.True(t, found) {
+		t.FailNow()
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var (
+		c    = New()
+		keys = make([]string, 0, 3)
+		vals = make(map[string]interface{})
+	)
+
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf(keyTpl, 10+i)
+		keys = append(keys, key)
+		vals[key] = i
+	}
+	defer c.Del(keys...)
+
+	t.Run("cacher.MSet", func(t *testing.T) {
+		// Try to cache values:
+		if err := c.MSet(vals, time.Minute); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// Try to get ttl of cached value:
+		if ttl, err := c.TTL(keys[1]); !assert.NoError(t, err) || !assert.Equal(t, time.Minute, ttl) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("cacher.MGet", func(t *testing.T) {
+		var (
+			results    = make([]int, len(keys))
+			missingKey = fmt.Sprintf(keyTpl, 10+len(keys))
+			dst        = map[string]interface{}{missingKey: new(int)}
+		)
+
+		for i, key := range keys {
+			dst[key] = &results[i]
+		}
+
+		// Try to get values from cache:
+		missing, err := c.MGet(dst)
+		if !assert.NoError(t, err) || !assert.Equal(t, []string{missingKey}, missing) {
+			t.FailNow()
+		}
+
+		assert.Equal(t, []int{0, 1, 2}, results)
+	})
+
+	t.Run("cacher.Del", func(t *testing.T) {
+		// Try to remove keys from cache:
+		if err := c.Del(keys...); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// Try to check exists keys:
+		if count, err := c.Exists(keys...); !assert.NoError(t, err) || !assert.Equal(t, int64(0), count) {
+			t.FailNow()
+		}
+
+		// Try to remove nothing:
+		assert.NoError(t, c.Del())
+	})
+}
+
+func TestScan(t *testing.T) {
+	var (
+		c    = New()
+		vals = make(map[string]interface{})
+	)
+
+	for i := 0; i < 20; i++ {
+		vals[fmt.Sprintf("test:scan:%d", i)] = i
+	}
+	vals["test:other:0"] = 0
+	defer c.Del("test:other:0")
+
+	if err := c.MSet(vals, time.Minute); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	t.Run("cacher.Scan", func(t *testing.T) {
+		var (
+			iter = c.Scan("test:scan:*", 5)
+			keys = make(map[string]struct{})
+		)
+
+		// Try to iterate over keys:
+		for iter.Next() {
+			keys[iter.Val()] = struct{}{}
+		}
+
+		if !assert.NoError(t, iter.Err()) || !assert.Len(t, keys, 20) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("cacher.DelPattern", func(t *testing.T) {
+		// Try to remove keys by pattern:
+		if err := c.DelPattern("test:scan:*"); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if keys, err := c.Keys("test:scan:*"); !assert.NoError(t, err) || !assert.Empty(t, keys) {
+			t.FailNow()
+		}
+
+		// Other keys should stay untouched:
+		if count, err := c.Exists("test:other:0"); !assert.NoError(t, err) || !assert.Equal(t, int64(1), count) {
+			t.FailNow()
+		}
+	})
+}
+
+func TestContext(t *testing.T) {
+	var (
+		val int
+		key = fmt.Sprintf(keyTpl, 5)
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := New().WithContext(ctx)
+
+	if err := c.Set(key, 1, time.Minute); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if err := c.Get(key, &val); !assert.NoError(t, err) || !assert.Equal(t, 1, val) {
+		t.FailNow()
+	}
+
+	cancel()
+
+	if err := c.Set(key, 2, time.Minute); !assert.Equal(t, context.Canceled, err) {
+		t.FailNow()
+	}
+
+	iter := c.Scan("*", 0)
+	if !assert.False(t, iter.Next()) || !assert.Equal(t, context.Canceled, iter.Err()) {
+		t.FailNow()
+	}
+
+	// key isn't changed by cancelled Set:
+	if err := c.WithContext(context.Background()).Get(key, &val); !assert.NoError(t, err) || !assert.Equal(t, 1, val) {
+		t.FailNow()
+	}
+}
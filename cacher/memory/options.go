@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/cryptopay-dev/yaga/cacher"
+)
+
+// DefaultCleanupInterval used when Options.CleanupInterval is not set
+const DefaultCleanupInterval = time.Minute
+
+// Options for creating memory-cacher
+type Options struct {
+	// CleanupInterval between background evictions of expired keys,
+	// negative value disables background eviction
+	CleanupInterval time.Duration
+	// Codec to serialize values, cacher.Raw by default
+	Codec cacher.Codec
+}
+
+// newOptions converts slice of closures to Options-field
+func newOptions(opts ...Option) Options {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.CleanupInterval == 0 {
+		options.CleanupInterval = DefaultCleanupInterval
+	}
+
+	if options.Codec == nil {
+		options.Codec = cacher.Raw
+	}
+
+	return options
+}
+
+// Option closure
+type Option func(*Options)
+
+// CleanupInterval closure to set field in Options
+func CleanupInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.CleanupInterval = interval
+	}
+}
+
+// Codec closure to set field in Options
+func Codec(codec cacher.Codec) Option {
+	return func(o *Options) {
+		o.Codec = codec
+	}
+}
@@ -0,0 +1,176 @@
+package cacher
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// prefixed is a Cacher view, that namespaces every key
+type prefixed struct {
+	cacher  Cacher
+	prefix  string
+	pattern string
+}
+
+// taggedPrefixed is a prefixed view of Tagger, tags are namespaced too
+type taggedPrefixed struct {
+	*prefixed
+	tagger Tagger
+}
+
+// prefixedIterator strips prefix from keys
+type prefixedIterator struct {
+	Iterator
+	prefix string
+}
+
+// WithPrefix returns Cacher, that transparently adds prefix to every key
+// and strips it from keys returned by Keys and Scan.
+// Nested prefixes are joined: WithPrefix(WithPrefix(c, "a:"), "b:") uses "a:b:".
+// When c implements Tagger, the view implements it too and prefixes tags.
+func WithPrefix(c Cacher, prefix string) Cacher {
+	switch p := c.(type) {
+	case *prefixed:
+		c, prefix = p.cacher, p.prefix+prefix
+	case *taggedPrefixed:
+		c, prefix = p.cacher, p.prefix+prefix
+	}
+
+	view := &prefixed{
+		cacher:  c,
+		prefix:  prefix,
+		pattern: escapePattern(prefix),
+	}
+
+	if t, ok := c.(Tagger); ok {
+		return &taggedPrefixed{prefixed: view, tagger: t}
+	}
+
+	return view
+}
+
+// escapePattern escapes glob-style special characters
+func escapePattern(s string) string {
+	result := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			result = append(result, '\\')
+		}
+		result = append(result, s[i])
+	}
+	return string(result)
+}
+
+func (p *prefixed) key(key string) string {
+	return p.prefix + key
+}
+
+func (p *prefixed) keys(keys []string) []string {
+	result := make([]string, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, p.prefix+key)
+	}
+	return result
+}
+
+func (p *prefixed) strip(keys []string) []string {
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, p.prefix)
+	}
+	return keys
+}
+
+func (p *prefixed) vals(vals map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(vals))
+	for key, val := range vals {
+		result[p.prefix+key] = val
+	}
+	return result
+}
+
+// Get key with prefix to val interface
+func (p *prefixed) Get(key string, val interface{}) error {
+	return p.cacher.Get(p.key(key), val)
+}
+
+// Lookup key with prefix to val interface
+func (p *prefixed) Lookup(key string, val interface{}) (bool, error) {
+	return p.cacher.Lookup(p.key(key), val)
+}
+
+// Set key with prefix
+func (p *prefixed) Set(key string, val interface{}, timeout time.Duration) error {
+	return p.cacher.Set(p.key(key), val, timeout)
+}
+
+// MGet keys with prefix, returned missing keys are without prefix
+func (p *prefixed) MGet(vals map[string]interface{}) ([]string, error) {
+	missing, err := p.cacher.MGet(p.vals(vals))
+	return p.strip(missing), err
+}
+
+// MSet keys with prefix
+func (p *prefixed) MSet(vals map[string]interface{}, timeout time.Duration) error {
+	return p.cacher.MSet(p.vals(vals), timeout)
+}
+
+// Keys fetch by pattern with prefix, returned keys are without prefix
+func (p *prefixed) Keys(pattern string) ([]string, error) {
+	keys, err := p.cacher.Keys(p.pattern + pattern)
+	return p.strip(keys), err
+}
+
+// Scan iterates over keys by pattern with prefix, keys are without prefix
+func (p *prefixed) Scan(pattern string, count int64) Iterator {
+	return &prefixedIterator{
+		Iterator: p.cacher.Scan(p.pattern+pattern, count),
+		prefix:   p.prefix,
+	}
+}
+
+// DelPattern removes keys by pattern with prefix
+func (p *prefixed) DelPattern(pattern string) error {
+	return p.cacher.DelPattern(p.pattern + pattern)
+}
+
+// TTL fetch for key with prefix
+func (p *prefixed) TTL(key string) (time.Duration, error) {
+	return p.cacher.TTL(p.key(key))
+}
+
+// Expire sets for key with prefix
+func (p *prefixed) Expire(key string, duration time.Duration) error {
+	return p.cacher.Expire(p.key(key), duration)
+}
+
+// Del keys with prefix
+func (p *prefixed) Del(keys ...string) error {
+	return p.cacher.Del(p.keys(keys)...)
+}
+
+// Exists check keys with prefix
+func (p *prefixed) Exists(keys ...string) (int64, error) {
+	return p.cacher.Exists(p.keys(keys)...)
+}
+
+// WithContext returns prefixed view of Cacher bound to ctx
+func (p *prefixed) WithContext(ctx context.Context) Cacher {
+	return WithPrefix(p.cacher.WithContext(ctx), p.prefix)
+}
+
+// SetWithTags sets key with prefix and adds it to every tag with prefix
+func (p *taggedPrefixed) SetWithTags(key string, val interface{}, timeout time.Duration, tags ...string) error {
+	return p.tagger.SetWithTags(p.key(key), val, timeout, p.keys(tags)...)
+}
+
+// InvalidateTags with prefix
+func (p *taggedPrefixed) InvalidateTags(tags ...string) error {
+	return p.tagger.InvalidateTags(p.keys(tags)...)
+}
+
+// Val returns current key without prefix
+func (i *prefixedIterator) Val() string {
+	return strings.TrimPrefix(i.Iterator.Val(), i.prefix)
+}
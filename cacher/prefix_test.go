@@ -0,0 +1,116 @@
+package cacher_test
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/cacher"
+	"github.com/cryptopay-dev/yaga/cacher/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPrefix(t *testing.T) {
+	var (
+		base   = memory.New()
+		first  = cacher.WithPrefix(base, "first:")
+		second = cacher.WithPrefix(base, "second:")
+		nested = cacher.WithPrefix(first, "nested:")
+	)
+
+	t.Run("keys should be namespaced", func(t *testing.T) {
+		if err := first.Set("key", 1, time.Minute); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if err := second.Set("key", 2, time.Minute); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if err := nested.Set("key", 3, time.Minute); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		for key, expected := range map[string]int{
+			"first:key":        1,
+			"second:key":       2,
+			"first:nested:key": 3,
+		} {
+			var val int
+			if found, err := base.Lookup(key, &val); !assert.NoError(t, err) || !assert.True(t, found) {
+				t.FailNow()
+			}
+			assert.Equal(t, expected, val)
+		}
+	})
+
+	t.Run("keys should be stripped", func(t *testing.T) {
+		keys, err := first.Keys("*")
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		sort.Strings(keys)
+		assert.Equal(t, []string{"key", "nested:key"}, keys)
+
+		var (
+			scanned []string
+			iter    = nested.Scan("*", 10)
+		)
+		for iter.Next() {
+			scanned = append(scanned, iter.Val())
+		}
+		assert.NoError(t, iter.Err())
+		assert.Equal(t, []string{"key"}, scanned)
+	})
+
+	t.Run("batch operations should be namespaced", func(t *testing.T) {
+		var val int
+		missing, err := second.MGet(map[string]interface{}{
+			"key":     &val,
+			"missing": new(int),
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, []string{"missing"}, missing)
+		assert.Equal(t, 2, val)
+
+		if count, err := nested.Exists("key", "missing"); !assert.NoError(t, err) || !assert.Equal(t, int64(1), count) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("prefix with special characters should be escaped", func(t *testing.T) {
+		special := cacher.WithPrefix(base, "sp*cial:")
+		if err := special.Set("key", 1, time.Minute); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if err := base.Set("spe-cial:key", 1, time.Minute); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		keys, err := special.Keys("*")
+		if !assert.NoError(t, err) || !assert.Equal(t, []string{"key"}, keys) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("delete should be namespaced", func(t *testing.T) {
+		if err := first.DelPattern("*"); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if count, err := base.Exists("first:key", "first:nested:key", "second:key"); !assert.NoError(t, err) || !assert.Equal(t, int64(1), count) {
+			t.FailNow()
+		}
+
+		if err := second.Del("key"); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if count, err := base.Exists("second:key"); !assert.NoError(t, err) || !assert.Equal(t, int64(0), count) {
+			t.FailNow()
+		}
+	})
+}
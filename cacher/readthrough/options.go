@@ -0,0 +1,107 @@
+package readthrough
+
+import (
+	"time"
+
+	"github.com/cryptopay-dev/yaga/cacher"
+	"github.com/cryptopay-dev/yaga/locker"
+	"github.com/cryptopay-dev/yaga/logger"
+	"github.com/cryptopay-dev/yaga/logger/nop"
+)
+
+// DefaultLockTimeout used when Options.LockTimeout is not set
+const DefaultLockTimeout = 10 * time.Second
+
+// Options for creating ReadThrough instance
+type Options struct {
+	// Cacher to store values
+	Cacher cacher.Cacher
+	// Locker to protect values from concurrent loading
+	Locker locker.Locker
+	// Logger for background refresh errors, nop-logger by default
+	Logger logger.Logger
+	// Codec to serialize values, cacher.JSON by default
+	Codec cacher.Codec
+	// LockTimeout for loading of value
+	LockTimeout time.Duration
+	// StaleTTL is how long expired value can be served
+	// while it is refreshed in background
+	StaleTTL time.Duration
+	// Beta of probabilistic early refresh, zero disables it,
+	// values greater than one favor earlier refreshes
+	Beta float64
+}
+
+// Option closure
+type Option func(*Options)
+
+// newOptions converts slice of closures to Options-field
+func newOptions(opts ...Option) Options {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.Logger == nil {
+		options.Logger = nop.New()
+	}
+
+	if options.Codec == nil {
+		options.Codec = cacher.JSON
+	}
+
+	if options.LockTimeout <= 0 {
+		options.LockTimeout = DefaultLockTimeout
+	}
+
+	return options
+}
+
+// Cacher closure to set field in Options
+func Cacher(c cacher.Cacher) Option {
+	return func(o *Options) {
+		o.Cacher = c
+	}
+}
+
+// Locker closure to set field in Options
+func Locker(l locker.Locker) Option {
+	return func(o *Options) {
+		o.Locker = l
+	}
+}
+
+// Logger closure to set field in Options
+func Logger(l logger.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// Codec closure to set field in Options
+func Codec(codec cacher.Codec) Option {
+	return func(o *Options) {
+		o.Codec = codec
+	}
+}
+
+// LockTimeout closure to set field in Options
+func LockTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.LockTimeout = timeout
+	}
+}
+
+// StaleTTL closure to set field in Options
+func StaleTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.StaleTTL = ttl
+	}
+}
+
+// Beta closure to set field in Options
+func Beta(beta float64) Option {
+	return func(o *Options) {
+		o.Beta = beta
+	}
+}
@@ -0,0 +1,235 @@
+package readthrough
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/cacher"
+	"github.com/cryptopay-dev/yaga/locker"
+)
+
+const (
+	lockSuffix = ":lock"
+	headerSize = 16
+)
+
+var (
+	// ErrNoCacher issued when Options.Cacher is not set
+	ErrNoCacher = errors.New("options hasn't cacher")
+
+	// ErrNoLocker issued when Options.Locker is not set
+	ErrNoLocker = errors.New("options hasn't locker")
+
+	// ErrNotLoaded issued when value is missing and lock
+	// is held by another process for longer than locker waits
+	ErrNotLoaded = errors.New("value is not loaded: lock is held by another process")
+
+	// ErrBadEnvelope issued when cached value was not stored by ReadThrough
+	ErrBadEnvelope = errors.New("bad cached value")
+)
+
+// ReadThrough interface of stampede-protected read-through cache
+type ReadThrough interface {
+	Fetch(key string, val interface{}, ttl time.Duration, loader cacher.Loader) error
+}
+
+type readThrough struct {
+	options    Options
+	refreshing sync.Map
+}
+
+// envelope of cached value with metadata for early refresh
+type envelope struct {
+	// expiry of value, zero when value has no ttl
+	expiry time.Time
+	// delta is a duration of value loading
+	delta time.Duration
+	data  []byte
+}
+
+// New creates instance of ReadThrough
+func New(opts ...Option) (ReadThrough, error) {
+	var options = newOptions(opts...)
+
+	if options.Cacher == nil {
+		return nil, ErrNoCacher
+	}
+
+	if options.Locker == nil {
+		return nil, ErrNoLocker
+	}
+
+	return &readThrough{options: options}, nil
+}
+
+// Fetch key to val, when key is missing only one process loads it,
+// while others wait for the lock. Expired (stale) values and values
+// chosen for early refresh are served immediately and refreshed in background.
+func (r *readThrough) Fetch(key string, val interface{}, ttl time.Duration, loader cacher.Loader) error {
+	env, found, err := r.get(key)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		if r.shouldRefresh(env, time.Now()) {
+			r.refresh(key, env.expiry, ttl, loader)
+		}
+		return r.options.Codec.Unmarshal(env.data, val)
+	}
+
+	if env, err = r.load(key, time.Time{}, ttl, loader); err != nil {
+		return err
+	}
+
+	return r.options.Codec.Unmarshal(env.data, val)
+}
+
+// shouldRefresh checks that value is expired or chosen for early refresh
+// (see "Optimal Probabilistic Cache Stampede Prevention", Vattani et al.)
+func (r *readThrough) shouldRefresh(env envelope, now time.Time) bool {
+	if env.expiry.IsZero() {
+		return false
+	}
+
+	if !now.Before(env.expiry) {
+		return true
+	}
+
+	if r.options.Beta <= 0 {
+		return false
+	}
+
+	gap := -float64(env.delta) * r.options.Beta * math.Log(1-rand.Float64())
+
+	return now.Add(time.Duration(gap)).After(env.expiry)
+}
+
+// refresh loads value in background, only one refresh per key runs in process
+func (r *readThrough) refresh(key string, seen time.Time, ttl time.Duration, loader cacher.Loader) {
+	if _, busy := r.refreshing.LoadOrStore(key, struct{}{}); busy {
+		return
+	}
+
+	go func() {
+		defer r.refreshing.Delete(key)
+
+		if _, err := r.load(key, seen, ttl, loader); err != nil {
+			r.options.Logger.Warnf("ReadThrough refresh error: key=%s, error=%v", key, err)
+		}
+	}()
+}
+
+// load value under lock, value is not loaded when another
+// process has already stored value newer than seen expiry
+func (r *readThrough) load(key string, seen time.Time, ttl time.Duration, loader cacher.Loader) (env envelope, err error) {
+	var found bool
+
+	lockErr := r.options.Locker.Run(key+lockSuffix, r.options.LockTimeout, func(context.Context) {
+		if env, found, err = r.get(key); err != nil || found && fresh(env, seen) {
+			return
+		}
+
+		env, err = r.store(key, ttl, loader)
+	})
+
+	switch lockErr {
+	case nil:
+		return
+	case locker.ErrNotObtained:
+		// lock is held by another process, which could store the value already
+		if env, found, err = r.get(key); err == nil && !found {
+			err = ErrNotLoaded
+		}
+		return
+	default:
+		return envelope{}, lockErr
+	}
+}
+
+// fresh checks that value was stored after the seen one
+func fresh(env envelope, seen time.Time) bool {
+	return env.expiry.IsZero() || env.expiry.After(seen)
+}
+
+// store calls loader and puts its result to cache
+func (r *readThrough) store(key string, ttl time.Duration, loader cacher.Loader) (envelope, error) {
+	start := time.Now()
+
+	val, err := loader()
+	if err != nil {
+		return envelope{}, err
+	}
+
+	data, err := r.options.Codec.Marshal(val)
+	if err != nil {
+		return envelope{}, err
+	}
+
+	var (
+		now        = time.Now()
+		expiration time.Duration
+		env        = envelope{delta: now.Sub(start), data: data}
+	)
+
+	if ttl > 0 {
+		env.expiry = now.Add(ttl)
+		expiration = ttl + r.options.StaleTTL
+	}
+
+	return env, r.options.Cacher.Set(key, encode(env), expiration)
+}
+
+// get envelope from cache
+func (r *readThrough) get(key string) (envelope, bool, error) {
+	var buf []byte
+
+	found, err := r.options.Cacher.Lookup(key, &buf)
+	if err != nil || !found {
+		return envelope{}, false, err
+	}
+
+	env, err := decode(buf)
+	if err != nil {
+		return envelope{}, false, err
+	}
+
+	return env, true, nil
+}
+
+// encode envelope to bytes: expiry (unix nano), delta and data
+func encode(env envelope) []byte {
+	var expiry int64
+	if !env.expiry.IsZero() {
+		expiry = env.expiry.UnixNano()
+	}
+
+	buf := make([]byte, headerSize+len(env.data))
+	binary.BigEndian.PutUint64(buf[0:8], uint64(expiry))
+	binary.BigEndian.PutUint64(buf[8:16], uint64(env.delta))
+	copy(buf[headerSize:], env.data)
+
+	return buf
+}
+
+// decode envelope from bytes
+func decode(buf []byte) (envelope, error) {
+	if len(buf) < headerSize {
+		return envelope{}, ErrBadEnvelope
+	}
+
+	var env envelope
+
+	if expiry := int64(binary.BigEndian.Uint64(buf[0:8])); expiry != 0 {
+		env.expiry = time.Unix(0, expiry)
+	}
+	env.delta = time.Duration(binary.BigEndian.Uint64(buf[8:16]))
+	env.data = buf[headerSize:]
+
+	return env, nil
+}
@@ -0,0 +1,212 @@
+package readthrough
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/cacher/memory"
+	"github.com/cryptopay-dev/yaga/locker"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+// testLocker emulates locker.Locker in a single process
+type testLocker struct {
+	mu    sync.Mutex
+	locks map[string]*sync.Mutex
+	busy  bool
+}
+
+func (l *testLocker) Run(key string, timeout time.Duration, handler func(ctx context.Context)) error {
+	l.mu.Lock()
+	if l.busy {
+		l.mu.Unlock()
+		return locker.ErrNotObtained
+	}
+	m, ok := l.locks[key]
+	if !ok {
+		m = new(sync.Mutex)
+		l.locks[key] = m
+	}
+	l.mu.Unlock()
+
+	m.Lock()
+	defer m.Unlock()
+	handler(context.Background())
+	return nil
+}
+
+func (l *testLocker) TryRun(key string, timeout time.Duration, handler func(ctx context.Context)) error {
+	return l.Run(key, timeout, handler)
+}
+
+// Obtain is not used by ReadThrough
+func (l *testLocker) Obtain(string, time.Duration) (locker.Handle, error) {
+	return nil, locker.ErrNotObtained
+}
+
+func (l *testLocker) WithContext(context.Context) locker.Locker {
+	return l
+}
+
+func newTestReadThrough(t *testing.T, opts ...Option) (ReadThrough, *testLocker) {
+	l := &testLocker{locks: make(map[string]*sync.Mutex)}
+
+	rt, err := New(append([]Option{Cacher(memory.New()), Locker(l)}, opts...)...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return rt, l
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Locker(new(testLocker)))
+	assert.Equal(t, ErrNoCacher, err)
+
+	_, err = New(Cacher(memory.New()))
+	assert.Equal(t, ErrNoLocker, err)
+}
+
+func TestFetch(t *testing.T) {
+	type item struct {
+		Name string
+	}
+
+	t.Run("should load value once", func(t *testing.T) {
+		var (
+			rt, _ = newTestReadThrough(t)
+			calls = atomic.NewInt32(0)
+			wg    sync.WaitGroup
+		)
+
+		loader := func() (interface{}, error) {
+			calls.Inc()
+			time.Sleep(10 * time.Millisecond)
+			return item{Name: "name"}, nil
+		}
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				var val item
+				if err := rt.Fetch("key", &val, time.Minute, loader); assert.NoError(t, err) {
+					assert.Equal(t, "name", val.Name)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("should return loader error", func(t *testing.T) {
+		var (
+			val    item
+			rt, _  = newTestReadThrough(t)
+			errBad = errors.New("bad")
+		)
+
+		err := rt.Fetch("key", &val, time.Minute, func() (interface{}, error) {
+			return nil, errBad
+		})
+
+		assert.Equal(t, errBad, err)
+	})
+
+	t.Run("should fail when lock is busy", func(t *testing.T) {
+		var (
+			val   item
+			rt, l = newTestReadThrough(t)
+		)
+
+		l.busy = true
+		err := rt.Fetch("key", &val, time.Minute, func() (interface{}, error) {
+			return item{}, nil
+		})
+
+		assert.Equal(t, ErrNotLoaded, err)
+	})
+
+	t.Run("should serve stale value and refresh it", func(t *testing.T) {
+		var (
+			val     int
+			rt, _   = newTestReadThrough(t, StaleTTL(time.Minute))
+			calls   = atomic.NewInt32(0)
+			refresh = make(chan struct{})
+		)
+
+		loader := func() (interface{}, error) {
+			if calls.Inc() > 1 {
+				defer close(refresh)
+			}
+			return calls.Load(), nil
+		}
+
+		if err := rt.Fetch("key", &val, time.Millisecond, loader); !assert.NoError(t, err) || !assert.Equal(t, 1, val) {
+			t.FailNow()
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		// stale value is served, refresh is started:
+		if err := rt.Fetch("key", &val, time.Minute, loader); !assert.NoError(t, err) || !assert.Equal(t, 1, val) {
+			t.FailNow()
+		}
+
+		select {
+		case <-refresh:
+		case <-time.After(time.Second):
+			assert.FailNow(t, "value was not refreshed")
+		}
+
+		// refresh is stored asynchronously:
+		time.Sleep(5 * time.Millisecond)
+
+		if err := rt.Fetch("key", &val, time.Minute, loader); !assert.NoError(t, err) || !assert.Equal(t, 2, val) {
+			t.FailNow()
+		}
+	})
+}
+
+func TestShouldRefresh(t *testing.T) {
+	var (
+		now = time.Now()
+		env = envelope{expiry: now.Add(time.Second), delta: time.Second}
+	)
+
+	rt := &readThrough{}
+	assert.False(t, rt.shouldRefresh(env, now))
+	assert.True(t, rt.shouldRefresh(env, now.Add(time.Second)))
+	assert.False(t, rt.shouldRefresh(envelope{}, now))
+
+	// with huge beta refresh is almost certain
+	rt.options.Beta = 1000
+	assert.True(t, rt.shouldRefresh(env, now))
+}
+
+func TestEnvelope(t *testing.T) {
+	env := envelope{
+		expiry: time.Unix(0, time.Now().UnixNano()),
+		delta:  time.Second,
+		data:   []byte("data"),
+	}
+
+	decoded, err := decode(encode(env))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.True(t, env.expiry.Equal(decoded.expiry))
+	assert.Equal(t, env.delta, decoded.delta)
+	assert.Equal(t, env.data, decoded.data)
+
+	_, err = decode([]byte("bad"))
+	assert.Equal(t, ErrBadEnvelope, err)
+}
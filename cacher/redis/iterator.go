@@ -0,0 +1,40 @@
+package redis
+
+import "github.com/go-redis/redis"
+
+// iterator over SCAN-cursor, which stops when context of service is done
+type iterator struct {
+	service *service
+	iter    *redis.ScanIterator
+	err     error
+}
+
+// Next advances iterator, each fetch of next batch is bound to context
+func (i *iterator) Next() bool {
+	if i.err != nil {
+		return false
+	}
+
+	var next bool
+	if i.err = i.service.do(func() error {
+		next = i.iter.Next()
+		return nil
+	}); i.err != nil {
+		return false
+	}
+
+	return next
+}
+
+// Val returns current key
+func (i *iterator) Val() string {
+	return i.iter.Val()
+}
+
+// Err returns iteration error
+func (i *iterator) Err() error {
+	if i.err != nil {
+		return i.err
+	}
+	return i.iter.Err()
+}
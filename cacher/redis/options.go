@@ -1,10 +1,19 @@
 package redis
 
+import "github.com/cryptopay-dev/yaga/cacher"
+
+// DefaultTagPrefix used when Options.TagPrefix is not set
+const DefaultTagPrefix = "tag:"
+
 // Options for creating redis-cacher
 type Options struct {
 	Address  string
 	Password string
 	DB       int
+	// Codec to serialize values, cacher.Raw by default
+	Codec cacher.Codec
+	// TagPrefix for Redis sorted sets, that keep tag membership
+	TagPrefix string
 }
 
 // newOptions converts slice of closures to Options-field
@@ -13,6 +22,15 @@ func newOptions(opts ...Option) Options {
 	for _, o := range opts {
 		o(&options)
 	}
+
+	if options.Codec == nil {
+		options.Codec = cacher.Raw
+	}
+
+	if options.TagPrefix == "" {
+		options.TagPrefix = DefaultTagPrefix
+	}
+
 	return options
 }
 
@@ -39,3 +57,17 @@ func DB(db int) Option {
 		o.DB = db
 	}
 }
+
+// Codec closure to set field in Options
+func Codec(codec cacher.Codec) Option {
+	return func(o *Options) {
+		o.Codec = codec
+	}
+}
+
+// TagPrefix closure to set field in Options
+func TagPrefix(prefix string) Option {
+	return func(o *Options) {
+		o.TagPrefix = prefix
+	}
+}
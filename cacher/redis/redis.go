@@ -1,14 +1,23 @@
 package redis
 
 import (
+	"context"
+	"sort"
 	"time"
 
 	"github.com/cryptopay-dev/yaga/cacher"
+	"github.com/cryptopay-dev/yaga/helpers/traceid"
 	"github.com/go-redis/redis"
 )
 
+// delBatchSize is a count of keys removed by one DEL-command
+const delBatchSize = 512
+
 type service struct {
-	redis *redis.Client
+	redis     *redis.Client
+	codec     cacher.Codec
+	tagPrefix string
+	ctx       context.Context
 }
 
 // New creates redis-cache instance
@@ -21,48 +30,274 @@ func New(opts ...Option) cacher.Cacher {
 			Password: options.Password,
 			DB:       options.DB,
 		}),
+		codec:     options.Codec,
+		tagPrefix: options.TagPrefix,
+		ctx:       context.Background(),
 	}
 }
 
-// FromConnection creates redis-cache from existing redis-connection
-func FromConnection(client *redis.Client) cacher.Cacher {
-	return &service{redis: client}
+// FromConnection creates redis-cache from existing redis-connection,
+// connection options (Address, Password and DB) are ignored
+func FromConnection(client *redis.Client, opts ...Option) cacher.Cacher {
+	var options = newOptions(opts...)
+
+	return &service{
+		redis:     client,
+		codec:     options.Codec,
+		tagPrefix: options.TagPrefix,
+		ctx:       context.Background(),
+	}
+}
+
+// WithContext returns copy of redis-cache bound to ctx: command returns
+// ctx.Err() when ctx is done before it's finished, such command isn't
+// interrupted and its result is dropped. Errors are annotated with trace ID of ctx.
+func (s *service) WithContext(ctx context.Context) cacher.Cacher {
+	if ctx == nil {
+		panic("nil context")
+	}
+
+	c := *s
+	c.redis = s.redis.WithContext(ctx)
+	c.ctx = ctx
+	return &c
+}
+
+// do runs fn until ctx is done and annotates its error with trace ID
+func (s *service) do(fn func() error) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	var err error
+	if s.ctx.Done() == nil {
+		err = fn()
+	} else {
+		// buffered, so fn finishes when ctx is done earlier:
+		result := make(chan error, 1)
+		go func() { result <- fn() }()
+
+		select {
+		case err = <-result:
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		}
+	}
+
+	// redis.Nil is a miss and is compared by callers:
+	if err != redis.Nil {
+		return traceid.Wrap(s.ctx, err)
+	}
+
+	return redis.Nil
 }
 
 // Get key from redis-cache to val interface
 func (s *service) Get(key string, val interface{}) error {
-	if err := s.redis.Get(key).Scan(val); err != nil && err != redis.Nil {
-		return err
+	_, err := s.Lookup(key, val)
+	return err
+}
+
+// Lookup key from redis-cache to val interface and report whether key was found
+func (s *service) Lookup(key string, val interface{}) (bool, error) {
+	var data []byte
+	err := s.do(func() (err error) {
+		data, err = s.redis.Get(key).Bytes()
+		return
+	})
+	if err == redis.Nil {
+		return false, nil
+	} else if err != nil {
+		return false, err
 	}
-	return nil
+	return true, s.codec.Unmarshal(data, val)
 }
 
 // Set key to redis-cache with value, and expiration
 func (s *service) Set(key string, val interface{}, expiration time.Duration) error {
-	return s.redis.Set(key, val, expiration).Err()
+	data, err := s.codec.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return s.do(func() error {
+		return s.redis.Set(key, data, expiration).Err()
+	})
 }
 
-// Del key from redis-cache
-func (s *service) Del(key string) error {
-	return s.redis.Del(key).Err()
+// MGet keys from redis-cache to vals (map of key to pointer),
+// returns sorted keys which are missing
+func (s *service) MGet(vals map[string]interface{}) ([]string, error) {
+	var (
+		keys    = make([]string, 0, len(vals))
+		missing []string
+	)
+
+	if len(vals) == 0 {
+		return missing, nil
+	}
+
+	for key := range vals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var result []interface{}
+	err := s.do(func() (err error) {
+		result, err = s.redis.MGet(keys...).Result()
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, item := range result {
+		data, ok := item.(string)
+		if !ok {
+			missing = append(missing, keys[i])
+			continue
+		}
+
+		if err = s.codec.Unmarshal([]byte(data), vals[keys[i]]); err != nil {
+			return nil, err
+		}
+	}
+
+	return missing, nil
+}
+
+// MSet vals (map of key to value) to redis-cache with the same expiration
+func (s *service) MSet(vals map[string]interface{}, expiration time.Duration) error {
+	if len(vals) == 0 {
+		return nil
+	}
+
+	var items = make(map[string][]byte, len(vals))
+	for key, val := range vals {
+		data, err := s.codec.Marshal(val)
+		if err != nil {
+			return err
+		}
+		items[key] = data
+	}
+
+	return s.do(func() error {
+		pipe := s.redis.Pipeline()
+		defer pipe.Close()
+
+		for key, data := range items {
+			pipe.Set(key, data, expiration)
+		}
+
+		_, err := pipe.Exec()
+		return err
+	})
+}
+
+// Del keys from redis-cache, keys are removed by batches in pipeline
+func (s *service) Del(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if len(keys) <= delBatchSize {
+		return s.do(func() error {
+			return s.redis.Del(keys...).Err()
+		})
+	}
+
+	return s.do(func() error {
+		pipe := s.redis.Pipeline()
+		defer pipe.Close()
+
+		for start := 0; start < len(keys); start += delBatchSize {
+			end := start + delBatchSize
+			if end > len(keys) {
+				end = len(keys)
+			}
+			pipe.Del(keys[start:end]...)
+		}
+
+		_, err := pipe.Exec()
+		return err
+	})
 }
 
 // Exists check keys in redis-cache
 func (s *service) Exists(keys ...string) (int64, error) {
-	return s.redis.Exists(keys...).Result()
+	var count int64
+	err := s.do(func() (err error) {
+		count, err = s.redis.Exists(keys...).Result()
+		return
+	})
+	return count, err
 }
 
-// Keys fetch from redis-cache by pattern
+// Keys fetch from redis-cache by pattern using SCAN-iteration
 func (s *service) Keys(pattern string) ([]string, error) {
-	return s.redis.Keys(pattern).Result()
+	var (
+		keys = make([]string, 0)
+		seen = make(map[string]struct{})
+		iter = s.Scan(pattern, 0)
+	)
+
+	for iter.Next() {
+		// SCAN can return the same key several times
+		if _, ok := seen[iter.Val()]; ok {
+			continue
+		}
+		seen[iter.Val()] = struct{}{}
+		keys = append(keys, iter.Val())
+	}
+
+	return keys, iter.Err()
+}
+
+// Scan iterates over redis-cache keys by pattern using cursor
+func (s *service) Scan(pattern string, count int64) cacher.Iterator {
+	return &iterator{
+		service: s,
+		iter:    s.redis.Scan(0, pattern, count).Iterator(),
+	}
+}
+
+// DelPattern removes keys by pattern from redis-cache in batches
+func (s *service) DelPattern(pattern string) error {
+	var (
+		keys = make([]string, 0, delBatchSize)
+		iter = s.Scan(pattern, delBatchSize)
+	)
+
+	for iter.Next() {
+		if keys = append(keys, iter.Val()); len(keys) < delBatchSize {
+			continue
+		}
+
+		if err := s.Del(keys...); err != nil {
+			return err
+		}
+		keys = keys[:0]
+	}
+
+	if err := iter.Err(); err != nil {
+		return err
+	}
+
+	return s.Del(keys...)
 }
 
 // TTL fetch for key from redis-cache
 func (s *service) TTL(key string) (time.Duration, error) {
-	return s.redis.TTL(key).Result()
+	var ttl time.Duration
+	err := s.do(func() (err error) {
+		ttl, err = s.redis.TTL(key).Result()
+		return
+	})
+	return ttl, err
 }
 
 // Expire sets for key in redis-cache
 func (s *service) Expire(key string, duration time.Duration) error {
-	return s.redis.Expire(key, duration).Err()
+	return s.do(func() error {
+		return s.redis.Expire(key, duration).Err()
+	})
 }
@@ -1,12 +1,15 @@
 package redis
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/cryptopay-dev/yaga/cacher"
+	"github.com/cryptopay-dev/yaga/helpers/traceid"
 	"github.com/go-redis/redis"
 	"github.com/stretchr/testify/assert"
 )
@@ -61,6 +64,18 @@ func TestService(t *testing.T) {
 		})
 	})
 
+	t.Run("cacher.Lookup", func(t *testing.T) {
+		// Try to lookup value from cache:
+		if found, err := c.Lookup(key, &val); !assert.NoError(t, err) || !assert.True(t, found) {
+			t.FailNow()
+		}
+
+		// Try to lookup missing value from cache:
+		if found, err := c.Lookup(key+":missing", &val); !assert.NoError(t, err) || !assert.False(t, found) {
+			t.FailNow()
+		}
+	})
+
 	t.Run("cacher.TTL", func(t *testing.T) {
 		// Try to get ttl key from cache:
 		if ttl2, err := c.TTL(key); !assert.NoError(t, err) || !assert.Equal(t, ttl1, ttl2) {
@@ -106,3 +121,357 @@ func TestService(t *testing.T) {
 		}
 	})
 }
+
+func TestCodec(t *testing.T) {
+	type item struct {
+		Name  string
+		Count int
+	}
+
+	var (
+		key = fmt.Sprintf(keyTpl, 1)
+		src = item{Name: "name", Count: 42}
+		c   = New(
+			Address(os.Getenv("TEST_REDIS_ADDR")),
+			Codec(cacher.JSON),
+		)
+	)
+	defer c.Del(key)
+
+	// Try to cache struct:
+	if err := c.Set(key, src, time.Minute); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// Try to get struct from cache:
+	var dst item
+	if err := c.Get(key, &dst); !assert.NoError(t, err) || !assert.Equal(t, src, dst) {
+		t.FailNow()
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var (
+		c    = defaultCacher()
+		keys = make([]string, 0, 3)
+		vals = make(map[string]interface{})
+	)
+
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf(keyTpl, 10+i)
+		keys = append(keys, key)
+		vals[key] = i
+	}
+	defer c.Del(keys...)
+
+	t.Run("cacher.MSet", func(t *testing.T) {
+		// Try to cache values:
+		if err := c.MSet(vals, time.Minute); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// Try to get ttl of cached value:
+		if ttl, err := c.TTL(keys[1]); !assert.NoError(t, err) || !assert.Equal(t, time.Minute, ttl) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("cacher.MGet", func(t *testing.T) {
+		var (
+			results    = make([]int, len(keys))
+			missingKey = fmt.Sprintf(keyTpl, 10+len(keys))
+			dst        = map[string]interface{}{missingKey: new(int)}
+		)
+
+		for i, key := range keys {
+			dst[key] = &results[i]
+		}
+
+		// Try to get values from cache:
+		missing, err := c.MGet(dst)
+		if !assert.NoError(t, err) || !assert.Equal(t, []string{missingKey}, missing) {
+			t.FailNow()
+		}
+
+		assert.Equal(t, []int{0, 1, 2}, results)
+	})
+
+	t.Run("cacher.Del", func(t *testing.T) {
+		// Try to remove keys from cache:
+		if err := c.Del(keys...); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// Try to check exists keys:
+		if count, err := c.Exists(keys...); !assert.NoError(t, err) || !assert.Equal(t, int64(0), count) {
+			t.FailNow()
+		}
+
+		// Try to remove nothing:
+		assert.NoError(t, c.Del())
+	})
+}
+
+func TestDelBatches(t *testing.T) {
+	var (
+		c    = defaultCacher()
+		keys = make([]string, 0, delBatchSize+10)
+		vals = make(map[string]interface{})
+	)
+
+	for i := 0; i < cap(keys); i++ {
+		key := fmt.Sprintf("test:batch:%d", i)
+		keys = append(keys, key)
+		vals[key] = i
+	}
+
+	if err := c.MSet(vals, time.Minute); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// Try to remove keys by several batches:
+	if err := c.Del(keys...); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if count, err := c.Exists(keys...); !assert.NoError(t, err) || !assert.Equal(t, int64(0), count) {
+		t.FailNow()
+	}
+}
+
+func TestScan(t *testing.T) {
+	var (
+		c    = defaultCacher()
+		vals = make(map[string]interface{})
+	)
+
+	for i := 0; i < 20; i++ {
+		vals[fmt.Sprintf("test:scan:%d", i)] = i
+	}
+	vals["test:other:0"] = 0
+	defer c.Del("test:other:0")
+
+	if err := c.MSet(vals, time.Minute); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	t.Run("cacher.Scan", func(t *testing.T) {
+		var (
+			iter = c.Scan("test:scan:*", 5)
+			keys = make(map[string]struct{})
+		)
+
+		// Try to iterate over keys:
+		for iter.Next() {
+			keys[iter.Val()] = struct{}{}
+		}
+
+		if !assert.NoError(t, iter.Err()) || !assert.Len(t, keys, 20) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("cacher.DelPattern", func(t *testing.T) {
+		// Try to remove keys by pattern:
+		if err := c.DelPattern("test:scan:*"); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if keys, err := c.Keys("test:scan:*"); !assert.NoError(t, err) || !assert.Empty(t, keys) {
+			t.FailNow()
+		}
+
+		// Other keys should stay untouched:
+		if count, err := c.Exists("test:other:0"); !assert.NoError(t, err) || !assert.Equal(t, int64(1), count) {
+			t.FailNow()
+		}
+	})
+}
+
+func TestTags(t *testing.T) {
+	var (
+		c, ok = defaultCacher().(cacher.Tagger)
+		keys  = []string{"test:tags:0", "test:tags:1", "test:tags:2"}
+	)
+
+	if !assert.True(t, ok, "redis-cacher should implement cacher.Tagger") {
+		t.FailNow()
+	}
+
+	defer defaultCacher().Del(keys...)
+
+	t.Run("cacher.SetWithTags", func(t *testing.T) {
+		// Try to cache tagged values:
+		if err := c.SetWithTags(keys[0], 0, time.Minute, "first"); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if err := c.SetWithTags(keys[1], 1, time.Hour, "first", "second"); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if err := c.SetWithTags(keys[2], 2, time.Minute, "second"); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// Tag set should live as long as its longest key (expiration is set by Redis clock):
+		if ttl, err := defaultCacher().TTL(DefaultTagPrefix + "first"); !assert.NoError(t, err) || !assert.InDelta(t, time.Hour, ttl, float64(time.Second)) {
+			t.FailNow()
+		}
+
+		if ttl, err := defaultCacher().TTL(keys[0]); !assert.NoError(t, err) || !assert.Equal(t, time.Minute, ttl) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("cacher.InvalidateTags", func(t *testing.T) {
+		// Try to invalidate tag:
+		if err := c.InvalidateTags("first"); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if count, err := defaultCacher().Exists(keys...); !assert.NoError(t, err) || !assert.Equal(t, int64(1), count) {
+			t.FailNow()
+		}
+
+		if count, err := defaultCacher().Exists(keys[2], DefaultTagPrefix+"second"); !assert.NoError(t, err) || !assert.Equal(t, int64(2), count) {
+			t.FailNow()
+		}
+
+		if err := c.InvalidateTags("second"); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if count, err := defaultCacher().Exists(keys[2], DefaultTagPrefix+"first", DefaultTagPrefix+"second"); !assert.NoError(t, err) || !assert.Equal(t, int64(0), count) {
+			t.FailNow()
+		}
+	})
+}
+
+func TestTagsPruning(t *testing.T) {
+	var (
+		c      = defaultCacher().(cacher.Tagger)
+		client = redis.NewClient(&redis.Options{Addr: os.Getenv("TEST_REDIS_ADDR")})
+		tag    = DefaultTagPrefix + "pruning"
+		keys   = []string{"test:pruning:0", "test:pruning:1"}
+	)
+
+	defer client.Close()
+	defer client.Del(append(keys, tag)...)
+
+	if err := c.SetWithTags(keys[0], 0, 10*time.Millisecond, "pruning"); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if err := c.SetWithTags(keys[1], 1, time.Minute, "pruning"); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	// member of expired key is removed from tag set:
+	members, err := client.ZRange(tag, 0, -1).Result()
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{keys[1]}, members)
+	}
+}
+
+func TestTagsWithPrefix(t *testing.T) {
+	var (
+		first  = cacher.WithPrefix(defaultCacher(), "test:first:")
+		second = cacher.WithPrefix(defaultCacher(), "test:second:")
+	)
+
+	defer defaultCacher().Del("test:first:key", "test:second:key")
+
+	for _, c := range []cacher.Cacher{first, second} {
+		tagger, ok := c.(cacher.Tagger)
+		if !assert.True(t, ok, "prefixed redis-cacher should implement cacher.Tagger") {
+			t.FailNow()
+		}
+
+		if err := tagger.SetWithTags("key", 1, time.Minute, "shared"); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	// tags of namespaces don't affect each other:
+	if err := first.(cacher.Tagger).InvalidateTags("shared"); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if count, err := defaultCacher().Exists("test:first:key", "test:second:key"); !assert.NoError(t, err) || !assert.Equal(t, int64(1), count) {
+		t.FailNow()
+	}
+
+	assert.NoError(t, second.(cacher.Tagger).InvalidateTags("shared"))
+}
+
+func TestContext(t *testing.T) {
+	var (
+		val int
+		key = fmt.Sprintf(keyTpl, 0)
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	c := defaultCacher().WithContext(ctx)
+
+	if err := c.Set(key, 1, time.Minute); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if err := c.Get(key, &val); !assert.NoError(t, err) || !assert.Equal(t, 1, val) {
+		t.FailNow()
+	}
+
+	expired, stop := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer stop()
+
+	if err := c.WithContext(expired).Get(key, &val); !assert.Equal(t, context.DeadlineExceeded, err) {
+		t.FailNow()
+	}
+
+	cancel()
+
+	if err := c.Del(key); !assert.Equal(t, context.Canceled, err) {
+		t.FailNow()
+	}
+
+	iter := c.Scan("*", 0)
+	if !assert.False(t, iter.Next()) || !assert.Equal(t, context.Canceled, iter.Err()) {
+		t.FailNow()
+	}
+
+	if err := defaultCacher().Del(key); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	err := closedCacher().WithContext(traceid.With(context.Background(), "trace")).Get(key, &val)
+	if !assert.Error(t, err) || !assert.Contains(t, err.Error(), "trace") {
+		t.FailNow()
+	}
+
+	// server accepts connections, but never replies:
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer ln.Close()
+
+	silent := FromConnection(redis.NewClient(&redis.Options{
+		Addr:        ln.Addr().String(),
+		ReadTimeout: 5 * time.Second,
+	}))
+
+	short, stopShort := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer stopShort()
+
+	// running command is bounded by deadline of ctx:
+	start := time.Now()
+	if err := silent.WithContext(short).Get(key, &val); !assert.Equal(t, context.DeadlineExceeded, err) {
+		t.FailNow()
+	}
+	assert.True(t, time.Since(start) < time.Second)
+}
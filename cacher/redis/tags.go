@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+var (
+	// setWithTags sets KEYS[1] to ARGV[1] with ttl ARGV[2] (in ms, 0 - without ttl)
+	// and adds it to tag sets KEYS[2..n]. Tag set is a sorted set of keys scored
+	// by their expiration time (by Redis clock), members of expired keys are pruned
+	// on write, and tag set lives as long as its longest key.
+	setWithTags = redis.NewScript(`
+redis.replicate_commands()
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local ttl = tonumber(ARGV[2])
+local score = '+inf'
+
+if ttl > 0 then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+	score = now + ttl
+else
+	redis.call('SET', KEYS[1], ARGV[1])
+end
+
+for i = 2, #KEYS do
+	-- prune expired members, members which ttl was changed are rescored:
+	local stale = redis.call('ZRANGEBYSCORE', KEYS[i], '-inf', now, 'LIMIT', 0, 512)
+	for _, member in ipairs(stale) do
+		local pttl = redis.call('PTTL', member)
+		if pttl == -2 then
+			redis.call('ZREM', KEYS[i], member)
+		elseif pttl == -1 then
+			redis.call('ZADD', KEYS[i], '+inf', member)
+		else
+			redis.call('ZADD', KEYS[i], now + pttl, member)
+		end
+	end
+
+	redis.call('ZADD', KEYS[i], score, KEYS[1])
+
+	if redis.call('ZCOUNT', KEYS[i], '+inf', '+inf') > 0 then
+		redis.call('PERSIST', KEYS[i])
+	else
+		local last = redis.call('ZREVRANGE', KEYS[i], 0, 0, 'WITHSCORES')
+		redis.call('PEXPIREAT', KEYS[i], last[2])
+	end
+end
+
+return 1
+`)
+
+	// invalidateTags removes all keys of tag sets KEYS[1..n] and tag sets itself
+	invalidateTags = redis.NewScript(`
+local batch = 512
+
+for i = 1, #KEYS do
+	local keys = redis.call('ZRANGE', KEYS[i], 0, -1)
+	for j = 1, #keys, batch do
+		redis.call('DEL', unpack(keys, j, math.min(j + batch - 1, #keys)))
+	end
+	redis.call('DEL', KEYS[i])
+end
+
+return 1
+`)
+)
+
+// tagKeys returns keys of tag sets
+func (s *service) tagKeys(tags []string) []string {
+	keys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		keys = append(keys, s.tagPrefix+tag)
+	}
+	return keys
+}
+
+// SetWithTags sets key to redis-cache with value and expiration,
+// and adds key to every tag
+func (s *service) SetWithTags(key string, val interface{}, expiration time.Duration, tags ...string) error {
+	data, err := s.codec.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	var ttl int64
+	if expiration > 0 {
+		// sub-millisecond expiration is rounded up
+		if ttl = int64(expiration / time.Millisecond); ttl == 0 {
+			ttl = 1
+		}
+	}
+
+	keys := append([]string{key}, s.tagKeys(tags)...)
+
+	return s.do(func() error {
+		return setWithTags.Run(s.redis, keys, data, ttl).Err()
+	})
+}
+
+// InvalidateTags removes all keys of tags from redis-cache
+func (s *service) InvalidateTags(tags ...string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	keys := s.tagKeys(tags)
+
+	return s.do(func() error {
+		return invalidateTags.Run(s.redis, keys).Err()
+	})
+}
@@ -0,0 +1,105 @@
+package tiered
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// lru is a bounded in-process cache with least recently used eviction
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	list  *list.List
+	items map[string]*list.Element
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:  size,
+		list:  list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// get returns not expired value and marks it as recently used
+func (l *lru) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	e := el.Value.(*entry)
+	if !time.Now().Before(e.expiresAt) {
+		l.removeElement(el)
+		return nil, false
+	}
+
+	l.list.MoveToFront(el)
+
+	return e.value, true
+}
+
+// add value with ttl, least recently used value is evicted when size exceeded
+func (l *lru) add(key string, value []byte, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+
+	if el, ok := l.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value, e.expiresAt = value, expiresAt
+		l.list.MoveToFront(el)
+		return
+	}
+
+	l.items[key] = l.list.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+
+	if l.list.Len() > l.size {
+		l.removeElement(l.list.Back())
+	}
+}
+
+// remove keys
+func (l *lru) remove(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := l.items[key]; ok {
+			l.removeElement(el)
+		}
+	}
+}
+
+// purge removes all keys
+func (l *lru) purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.list.Init()
+	l.items = make(map[string]*list.Element)
+}
+
+// len returns count of keys
+func (l *lru) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.list.Len()
+}
+
+func (l *lru) removeElement(el *list.Element) {
+	l.list.Remove(el)
+	delete(l.items, el.Value.(*entry).key)
+}
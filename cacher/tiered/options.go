@@ -0,0 +1,112 @@
+package tiered
+
+import (
+	"time"
+
+	"github.com/cryptopay-dev/yaga/cacher"
+	"github.com/cryptopay-dev/yaga/logger"
+	"github.com/cryptopay-dev/yaga/logger/nop"
+	"github.com/go-redis/redis"
+)
+
+const (
+	// DefaultSize used when Options.Size is not set
+	DefaultSize = 1024
+
+	// DefaultTTL used when Options.TTL is not set
+	DefaultTTL = 5 * time.Second
+
+	// DefaultChannel used when Options.Channel is not set
+	DefaultChannel = "cacher:invalidate"
+)
+
+// Options for creating two-tier cacher
+type Options struct {
+	// Redis connection, used as a second tier and for invalidation messages
+	Redis *redis.Client
+	// Logger for invalidation errors, nop-logger by default
+	Logger logger.Logger
+	// Codec to serialize values, cacher.Raw by default
+	Codec cacher.Codec
+	// Size is a maximum count of keys in local cache
+	Size int
+	// TTL of keys in local cache
+	TTL time.Duration
+	// Channel for invalidation messages
+	Channel string
+}
+
+// Option closure
+type Option func(*Options)
+
+// newOptions converts slice of closures to Options-field
+func newOptions(opts ...Option) Options {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.Logger == nil {
+		options.Logger = nop.New()
+	}
+
+	if options.Codec == nil {
+		options.Codec = cacher.Raw
+	}
+
+	if options.Size <= 0 {
+		options.Size = DefaultSize
+	}
+
+	if options.TTL <= 0 {
+		options.TTL = DefaultTTL
+	}
+
+	if options.Channel == "" {
+		options.Channel = DefaultChannel
+	}
+
+	return options
+}
+
+// Redis closure to set field in Options
+func Redis(r *redis.Client) Option {
+	return func(o *Options) {
+		o.Redis = r
+	}
+}
+
+// Logger closure to set field in Options
+func Logger(l logger.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// Codec closure to set field in Options
+func Codec(codec cacher.Codec) Option {
+	return func(o *Options) {
+		o.Codec = codec
+	}
+}
+
+// Size closure to set field in Options
+func Size(size int) Option {
+	return func(o *Options) {
+		o.Size = size
+	}
+}
+
+// TTL closure to set field in Options
+func TTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = ttl
+	}
+}
+
+// Channel closure to set field in Options
+func Channel(channel string) Option {
+	return func(o *Options) {
+		o.Channel = channel
+	}
+}
@@ -0,0 +1,360 @@
+package tiered
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/cacher"
+	redisCacher "github.com/cryptopay-dev/yaga/cacher/redis"
+	"github.com/cryptopay-dev/yaga/helpers"
+	"github.com/cryptopay-dev/yaga/helpers/traceid"
+	"github.com/go-redis/redis"
+)
+
+// ErrNoRedis issued when Options.Redis is not set
+var ErrNoRedis = errors.New("options hasn't redis")
+
+// Cacher is a two-tier cacher.Cacher, which should be closed
+// to stop listening for invalidation messages
+type Cacher interface {
+	cacher.Cacher
+	Close() error
+}
+
+// message about changed keys, sent to other replicas
+type message struct {
+	ID    string   `json:"id"`
+	Keys  []string `json:"keys,omitempty"`
+	Purge bool     `json:"purge,omitempty"`
+}
+
+type service struct {
+	id      string
+	options Options
+	local   *lru
+	remote  cacher.Cacher
+	client  *redis.Client
+	pubsub  *redis.PubSub
+	ctx     context.Context
+}
+
+// New creates two-tier cacher: bounded in-process LRU with short TTL in front
+// of Redis. Replicas invalidate local copies of each other through Redis pub/sub,
+// in case of lost messages local copy lives no longer than Options.TTL.
+func New(opts ...Option) (Cacher, error) {
+	var options = newOptions(opts...)
+
+	if options.Redis == nil {
+		return nil, ErrNoRedis
+	}
+
+	s := &service{
+		id:      helpers.NewUUID(),
+		options: options,
+		local:   newLRU(options.Size),
+		// values are marshaled by service, so remote stores bytes as is
+		remote: redisCacher.FromConnection(options.Redis, redisCacher.Codec(cacher.Raw)),
+		client: options.Redis,
+		pubsub: options.Redis.Subscribe(options.Channel),
+		ctx:    context.Background(),
+	}
+
+	// wait for confirmation of subscription:
+	if _, err := s.pubsub.Receive(); err != nil {
+		s.pubsub.Close()
+		return nil, err
+	}
+
+	go s.listen(s.pubsub.Channel())
+
+	return s, nil
+}
+
+// Close stops listening for invalidation messages
+func (s *service) Close() error {
+	return s.pubsub.Close()
+}
+
+// WithContext returns two-tier cacher, which remote operations are bound to ctx
+// and annotate errors with its trace ID, local cache and subscription are shared
+// with the parent
+func (s *service) WithContext(ctx context.Context) cacher.Cacher {
+	if ctx == nil {
+		panic("nil context")
+	}
+
+	c := *s
+	c.remote = s.remote.WithContext(ctx)
+	c.client = s.client.WithContext(ctx)
+	c.ctx = ctx
+	return &c
+}
+
+// listen for invalidation messages from other replicas
+func (s *service) listen(ch <-chan *redis.Message) {
+	for msg := range ch {
+		var m message
+		if err := json.Unmarshal([]byte(msg.Payload), &m); err != nil {
+			s.options.Logger.Warnf("Tiered cacher bad message: %v", err)
+			continue
+		}
+
+		if m.ID == s.id {
+			continue
+		}
+
+		if m.Purge {
+			s.local.purge()
+			continue
+		}
+
+		s.local.remove(m.Keys...)
+	}
+}
+
+// publish invalidation message to other replicas
+func (s *service) publish(m message) error {
+	m.ID = s.id
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	if err = s.ctx.Err(); err != nil {
+		return err
+	}
+
+	return traceid.Wrap(s.ctx, s.client.Publish(s.options.Channel, data).Err())
+}
+
+// invalidate keys locally and on other replicas
+func (s *service) invalidate(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	s.local.remove(keys...)
+
+	return s.publish(message{Keys: keys})
+}
+
+// localTTL returns ttl of local copy
+func (s *service) localTTL(expiration time.Duration) time.Duration {
+	if expiration > 0 && expiration < s.options.TTL {
+		return expiration
+	}
+	return s.options.TTL
+}
+
+// Get key to val interface
+func (s *service) Get(key string, val interface{}) error {
+	_, err := s.Lookup(key, val)
+	return err
+}
+
+// Lookup key to val interface, local copy is used when exists
+func (s *service) Lookup(key string, val interface{}) (bool, error) {
+	if data, ok := s.local.get(key); ok {
+		return true, s.options.Codec.Unmarshal(data, val)
+	}
+
+	items, err := s.fetch(key)
+	if err != nil {
+		return false, err
+	}
+
+	item, found := items[key]
+	if !found {
+		return false, nil
+	}
+
+	s.local.add(key, item.data, s.localTTL(item.ttl))
+
+	return true, s.options.Codec.Unmarshal(item.data, val)
+}
+
+// remoteItem is a value fetched from Redis with its remaining ttl
+type remoteItem struct {
+	data []byte
+	ttl  time.Duration
+}
+
+// fetch keys from Redis with their remaining ttl in one round-trip,
+// missing keys are absent in result
+func (s *service) fetch(keys ...string) (map[string]remoteItem, error) {
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		pipe = s.client.Pipeline()
+		gets = make([]*redis.StringCmd, len(keys))
+		ttls = make([]*redis.DurationCmd, len(keys))
+	)
+
+	defer pipe.Close()
+
+	for i, key := range keys {
+		gets[i] = pipe.Get(key)
+		ttls[i] = pipe.PTTL(key)
+	}
+
+	if _, err := pipe.Exec(); err != nil && err != redis.Nil {
+		return nil, traceid.Wrap(s.ctx, err)
+	}
+
+	items := make(map[string]remoteItem, len(keys))
+	for i, key := range keys {
+		data, err := gets[i].Bytes()
+		if err == redis.Nil {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		// negative ttl of key without expiration is replaced by Options.TTL:
+		items[key] = remoteItem{data: data, ttl: ttls[i].Val()}
+	}
+
+	return items, nil
+}
+
+// Set key with value and expiration
+func (s *service) Set(key string, val interface{}, expiration time.Duration) error {
+	data, err := s.options.Codec.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	if err = s.remote.Set(key, data, expiration); err != nil {
+		return err
+	}
+
+	if err = s.invalidate(key); err != nil {
+		return err
+	}
+
+	s.local.add(key, data, s.localTTL(expiration))
+
+	return nil
+}
+
+// MGet keys to vals (map of key to pointer), local copies are used when exist
+func (s *service) MGet(vals map[string]interface{}) ([]string, error) {
+	var keys []string
+
+	for key, val := range vals {
+		if buf, ok := s.local.get(key); ok {
+			if err := s.options.Codec.Unmarshal(buf, val); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	items, err := s.fetch(keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, key := range keys {
+		item, found := items[key]
+		if !found {
+			missing = append(missing, key)
+			continue
+		}
+
+		s.local.add(key, item.data, s.localTTL(item.ttl))
+
+		if err = s.options.Codec.Unmarshal(item.data, vals[key]); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(missing)
+
+	return missing, nil
+}
+
+// MSet vals (map of key to value) with the same expiration
+func (s *service) MSet(vals map[string]interface{}, expiration time.Duration) error {
+	var (
+		keys  = make([]string, 0, len(vals))
+		items = make(map[string]interface{}, len(vals))
+	)
+
+	for key, val := range vals {
+		data, err := s.options.Codec.Marshal(val)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		items[key] = data
+	}
+
+	if err := s.remote.MSet(items, expiration); err != nil {
+		return err
+	}
+
+	return s.invalidate(keys...)
+}
+
+// Keys fetch from Redis by pattern
+func (s *service) Keys(pattern string) ([]string, error) {
+	return s.remote.Keys(pattern)
+}
+
+// Scan iterates over Redis keys by pattern
+func (s *service) Scan(pattern string, count int64) cacher.Iterator {
+	return s.remote.Scan(pattern, count)
+}
+
+// DelPattern removes keys by pattern, local caches are purged
+func (s *service) DelPattern(pattern string) error {
+	if err := s.remote.DelPattern(pattern); err != nil {
+		return err
+	}
+
+	s.local.purge()
+
+	return s.publish(message{Purge: true})
+}
+
+// TTL fetch for key from Redis
+func (s *service) TTL(key string) (time.Duration, error) {
+	return s.remote.TTL(key)
+}
+
+// Expire sets for key
+func (s *service) Expire(key string, duration time.Duration) error {
+	if err := s.remote.Expire(key, duration); err != nil {
+		return err
+	}
+
+	return s.invalidate(key)
+}
+
+// Del keys
+func (s *service) Del(keys ...string) error {
+	if err := s.remote.Del(keys...); err != nil {
+		return err
+	}
+
+	return s.invalidate(keys...)
+}
+
+// Exists check keys in Redis
+func (s *service) Exists(keys ...string) (int64, error) {
+	return s.remote.Exists(keys...)
+}
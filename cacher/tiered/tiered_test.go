@@ -0,0 +1,184 @@
+package tiered
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCacher(t *testing.T, opts ...Option) Cacher {
+	client := redis.NewClient(&redis.Options{
+		Addr: os.Getenv("TEST_REDIS_ADDR"),
+	})
+
+	c, err := New(append([]Option{Redis(client), Channel("test:invalidate")}, opts...)...)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	return c
+}
+
+func waitFor(cond func() bool) bool {
+	limit := time.Now().Add(time.Second)
+	for time.Now().Before(limit) {
+		if cond() {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return false
+}
+
+func TestNew(t *testing.T) {
+	_, err := New()
+	assert.Equal(t, ErrNoRedis, err)
+}
+
+func TestTiered(t *testing.T) {
+	var (
+		first  = newTestCacher(t)
+		second = newTestCacher(t)
+		key    = "test:tiered:0"
+	)
+	defer first.Close()
+	defer second.Close()
+	defer first.Del(key)
+
+	t.Run("value should be cached locally", func(t *testing.T) {
+		if err := second.Set(key, 1, time.Minute); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		// remove key from Redis without invalidation:
+		if err := second.(*service).options.Redis.Del(key).Err(); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		var val int
+		if err := second.Get(key, &val); !assert.NoError(t, err) || !assert.Equal(t, 1, val) {
+			t.FailNow()
+		}
+
+		if err := first.Set(key, 1, time.Minute); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if err := first.Get(key, &val); !assert.NoError(t, err) || !assert.Equal(t, 1, val) {
+			t.FailNow()
+		}
+	})
+
+	t.Run("local copies should be invalidated on set", func(t *testing.T) {
+		if err := first.Set(key, 2, time.Minute); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		assert.True(t, waitFor(func() bool {
+			var val int
+			return second.Get(key, &val) == nil && val == 2
+		}))
+	})
+
+	t.Run("local copies should be invalidated on delete", func(t *testing.T) {
+		var val int
+		if err := second.Get(key, &val); !assert.NoError(t, err) || !assert.Equal(t, 2, val) {
+			t.FailNow()
+		}
+
+		if err := first.Del(key); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		assert.True(t, waitFor(func() bool {
+			found, err := second.Lookup(key, &val)
+			return err == nil && !found
+		}))
+	})
+
+	t.Run("batch operations", func(t *testing.T) {
+		vals := map[string]interface{}{key: 3}
+		if err := first.MSet(vals, time.Minute); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		var val int
+		missing, err := second.MGet(map[string]interface{}{
+			key:               &val,
+			"test:tiered:404": new(int),
+		})
+		if !assert.NoError(t, err) || !assert.Equal(t, []string{"test:tiered:404"}, missing) {
+			t.FailNow()
+		}
+		assert.Equal(t, 3, val)
+	})
+
+	t.Run("local copy should not outlive remote key", func(t *testing.T) {
+		var (
+			client = first.(*service).options.Redis
+			key    = "test:tiered:ttl"
+		)
+
+		if err := client.Set(key, "4", 20*time.Millisecond).Err(); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		for _, c := range []Cacher{first, second} {
+			var val int
+			if err := c.Get(key, &val); !assert.NoError(t, err) || !assert.Equal(t, 4, val) {
+				t.FailNow()
+			}
+
+			vals := map[string]interface{}{key: &val}
+			if missing, err := c.MGet(vals); !assert.NoError(t, err) || !assert.Empty(t, missing) {
+				t.FailNow()
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		var val int
+		found, err := second.Lookup(key, &val)
+		assert.NoError(t, err)
+		assert.False(t, found, "Local copy is served after remote key expired")
+	})
+
+	t.Run("local copies should be purged on delete by pattern", func(t *testing.T) {
+		if err := first.DelPattern("test:tiered:*"); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		assert.True(t, waitFor(func() bool {
+			return second.(*service).local.len() == 0
+		}))
+	})
+}
+
+func TestLRU(t *testing.T) {
+	l := newLRU(2)
+
+	l.add("a", []byte("a"), time.Minute)
+	l.add("b", []byte("b"), time.Minute)
+
+	// mark "a" as recently used
+	_, ok := l.get("a")
+	assert.True(t, ok)
+
+	l.add("c", []byte("c"), time.Minute)
+
+	_, ok = l.get("b")
+	assert.False(t, ok, "least recently used key should be evicted")
+
+	_, ok = l.get("a")
+	assert.True(t, ok)
+
+	l.add("d", []byte("d"), time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	_, ok = l.get("d")
+	assert.False(t, ok, "expired key should not be returned")
+	assert.Equal(t, 1, l.len())
+}
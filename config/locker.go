@@ -0,0 +1,66 @@
+package config
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers/backoff"
+	"github.com/cryptopay-dev/yaga/locker"
+	"github.com/cryptopay-dev/yaga/logger"
+	"github.com/go-redis/redis"
+)
+
+// ErrUnknownBackoff issued when Locker.Backoff is not fixed or exponential
+var ErrUnknownBackoff = errors.New("unknown backoff")
+
+// Locker retry policy configuration, zero values use locker defaults:
+type Locker struct {
+	// RetryCount of attempts after the first one, negative disables retries
+	RetryCount int `yaml:"retry_count"`
+	// Backoff between attempts: fixed (default) or exponential
+	Backoff string `yaml:"backoff"`
+	// RetryDelay between attempts, or initial delay of exponential backoff
+	RetryDelay time.Duration `yaml:"retry_delay" validate:"gte=0"`
+	// MaxRetryDelay limits exponential backoff
+	MaxRetryDelay time.Duration `yaml:"max_retry_delay" validate:"gte=0"`
+	// Jitter is a fraction of delay, which is randomly subtracted from it
+	Jitter float64 `yaml:"jitter" validate:"gte=0,lte=1"`
+	// WaitTimeout limits overall waiting for the lock
+	WaitTimeout time.Duration `yaml:"wait_timeout" validate:"gte=0"`
+}
+
+// Options converts configuration to locker.Option slice:
+func (l Locker) Options() ([]locker.Option, error) {
+	var (
+		delay = l.RetryDelay
+		opts  = []locker.Option{
+			locker.RetryCount(l.RetryCount),
+			locker.WaitTimeout(l.WaitTimeout),
+		}
+	)
+
+	if delay == 0 {
+		delay = locker.DefaultRetryDelay
+	}
+
+	switch l.Backoff {
+	case "", "fixed":
+		opts = append(opts, locker.RetryBackoff(backoff.Fixed(delay, l.Jitter)))
+	case "exponential":
+		opts = append(opts, locker.RetryBackoff(backoff.Exponential(delay, l.MaxRetryDelay, l.Jitter)))
+	default:
+		return nil, ErrUnknownBackoff
+	}
+
+	return opts, nil
+}
+
+// Locker creates locker.Locker with configured retry policy:
+func (l Locker) Locker(client *redis.Client, log logger.Logger) (locker.Locker, error) {
+	opts, err := l.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	return locker.New(append(opts, locker.Redis(client), locker.Logger(log))...), nil
+}
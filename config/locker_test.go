@@ -0,0 +1,87 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/locker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocker(t *testing.T) {
+	t.Run("should load from yaml", func(t *testing.T) {
+		var conf struct {
+			Locker Locker `yaml:"locker"`
+		}
+
+		err := Load(strings.NewReader(`
+locker:
+  retry_count: 5
+  backoff: exponential
+  retry_delay: 50ms
+  max_retry_delay: 1s
+  jitter: 0.2
+  wait_timeout: 3s
+`), &conf)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		assert.Equal(t, Locker{
+			RetryCount:    5,
+			Backoff:       "exponential",
+			RetryDelay:    50 * time.Millisecond,
+			MaxRetryDelay: time.Second,
+			Jitter:        0.2,
+			WaitTimeout:   3 * time.Second,
+		}, conf.Locker)
+
+		opts, err := conf.Locker.Options()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		var options locker.Options
+		for _, o := range opts {
+			o(&options)
+		}
+
+		assert.Equal(t, 5, options.RetryCount)
+		assert.Equal(t, 3*time.Second, options.WaitTimeout)
+
+		// delay is doubled up to max retry delay and decreased by jitter up to 20%:
+		for attempt, delay := range map[int]time.Duration{
+			1:  50 * time.Millisecond,
+			2:  100 * time.Millisecond,
+			3:  200 * time.Millisecond,
+			10: time.Second,
+		} {
+			backoff := options.Backoff(attempt)
+			assert.True(t, backoff <= delay, "attempt %d: %s > %s", attempt, backoff, delay)
+			assert.True(t, backoff >= delay*8/10, "attempt %d: %s < %s", attempt, backoff, delay*8/10)
+		}
+	})
+
+	t.Run("should use fixed default delay", func(t *testing.T) {
+		opts, err := Locker{}.Options()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		var options locker.Options
+		for _, o := range opts {
+			o(&options)
+		}
+
+		assert.Equal(t, 0, options.RetryCount)
+		assert.Equal(t, time.Duration(0), options.WaitTimeout)
+		assert.Equal(t, locker.DefaultRetryDelay, options.Backoff(1))
+		assert.Equal(t, locker.DefaultRetryDelay, options.Backoff(5))
+	})
+
+	t.Run("should fail on unknown backoff", func(t *testing.T) {
+		_, err := Locker{Backoff: "linear"}.Options()
+		assert.Equal(t, ErrUnknownBackoff, err)
+	})
+}
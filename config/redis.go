@@ -3,6 +3,8 @@ package config
 import (
 	"time"
 
+	"github.com/cryptopay-dev/yaga/cacher"
+	redisCacher "github.com/cryptopay-dev/yaga/cacher/redis"
 	"github.com/go-redis/redis"
 )
 
@@ -13,6 +15,10 @@ type Redis struct {
 	Password    string        `yaml:"password"`
 	PoolSize    int           `yaml:"pool_size" validate:"gte=0"`
 	PoolTimeout time.Duration `yaml:"pool_timeout" validate:"gte=0"`
+	// Codec for cacher values: raw (default), json, msgpack or gob
+	Codec string `yaml:"codec"`
+	// Prefix for cacher keys, to share one database between services
+	Prefix string `yaml:"prefix"`
 }
 
 // Connect to Redis and check connection:
@@ -32,3 +38,23 @@ func (r Redis) Connect() (*redis.Client, error) {
 
 	return cache, nil
 }
+
+// Cacher connects to Redis and creates cacher.Cacher with configured codec and prefix:
+func (r Redis) Cacher() (cacher.Cacher, error) {
+	codec, err := cacher.CodecByName(r.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := r.Connect()
+	if err != nil {
+		return nil, err
+	}
+
+	c := redisCacher.FromConnection(client, redisCacher.Codec(codec))
+	if r.Prefix != "" {
+		c = cacher.WithPrefix(c, r.Prefix)
+	}
+
+	return c, nil
+}
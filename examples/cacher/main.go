@@ -1,7 +1,10 @@
 package main
 
 import (
+	"time"
+
 	"github.com/cryptopay-dev/yaga/cacher"
+	"github.com/cryptopay-dev/yaga/cacher/memory"
 	"github.com/cryptopay-dev/yaga/cacher/redis"
 	redisStore "github.com/go-redis/redis"
 )
@@ -21,6 +24,13 @@ func variantTwo() cacher.Cacher {
 		redis.Address("127.0.0.1:6379"),
 		redis.Password(""),
 		redis.DB(0),
+		redis.Codec(cacher.JSON),
+	)
+}
+
+func variantThree() cacher.Cacher {
+	return memory.New(
+		memory.CleanupInterval(time.Minute),
 	)
 }
 
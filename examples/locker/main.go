@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -27,13 +28,26 @@ func main() {
 	for i := 0; i <= 10; i++ {
 		wg.Add(1)
 		go func(index int) {
-			lock.Run("my-key", time.Second*10, func() {
+			defer wg.Done()
+
+			err := lock.Run("my-key", time.Second*10, func(ctx context.Context) {
 				// Do some thing ... //
 
 				// For example
 				fmt.Println("Step :", index)
-				wg.Done()
+
+				// Long-running handler should stop, when lock is lost:
+				select {
+				case <-ctx.Done():
+					fmt.Println("Step interrupted :", index)
+				default:
+				}
 			})
+
+			// Lock can be held by other goroutine longer than locker waits:
+			if err == locker.ErrNotObtained {
+				fmt.Println("Step skipped :", index)
+			}
 		}(i)
 	}
 
@@ -65,7 +65,8 @@ func main() {
 
 	// worker will run every minutes at 12 secs
 	// example of scheduler like UNIX cron
-	// but with first element for seconds
+	// but with first element for seconds,
+	// its context is cancelled when workers are stopped
 	sched, err = workers.Parse("12 */1 * * * *")
 	if err != nil {
 		panic(err)
@@ -73,8 +74,15 @@ func main() {
 	err = workers.New(workers.Options{
 		Name:     "worker #3",
 		Schedule: sched,
-		Handler: func() {
+		ContextHandler: func(ctx context.Context) error {
 			fmt.Printf("[%s] worker #3 every minute at 12 secs\n", time.Now().Format("15:04:05"))
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second * 30):
+				return nil
+			}
 		},
 	})
 	if err != nil {
@@ -106,8 +114,13 @@ func main() {
 	// stopping workers
 	workers.Stop()
 
-	// wait until all workers will be stopped
-	workers.Wait()
+	// wait until all workers will be stopped, but no more than 5 seconds
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer waitCancel()
+
+	if err = workers.Wait(waitCtx); err != nil {
+		panic(err)
+	}
 
 	fmt.Printf("[%s] All workers are stopped\n", time.Now().Format("15:04:05"))
 }
@@ -0,0 +1,51 @@
+package backoff
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff returns delay before the next attempt, attempt starts from 1
+type Backoff func(attempt int) time.Duration
+
+// Fixed waits the same delay between attempts, jitter (from 0 to 1)
+// is a fraction of delay, which is randomly subtracted from it
+func Fixed(delay time.Duration, jitter float64) Backoff {
+	return func(int) time.Duration {
+		return withJitter(delay, jitter)
+	}
+}
+
+// Exponential doubles delay after each attempt up to max (zero means no limit),
+// jitter (from 0 to 1) is a fraction of delay, which is randomly subtracted from it
+func Exponential(base, max time.Duration, jitter float64) Backoff {
+	return func(attempt int) time.Duration {
+		delay := base
+		for i := 1; i < attempt && (max <= 0 || delay < max); i++ {
+			// stop on overflow:
+			if delay*2 <= delay {
+				break
+			}
+			delay *= 2
+		}
+
+		if max > 0 && delay > max {
+			delay = max
+		}
+
+		return withJitter(delay, jitter)
+	}
+}
+
+// withJitter randomly decreases delay by fraction of jitter
+func withJitter(delay time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return delay
+	}
+
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	return delay - time.Duration(jitter*rand.Float64()*float64(delay))
+}
@@ -0,0 +1,35 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackoff(t *testing.T) {
+	t.Run("fixed", func(t *testing.T) {
+		b := Fixed(100*time.Millisecond, 0)
+		assert.Equal(t, 100*time.Millisecond, b(1))
+		assert.Equal(t, 100*time.Millisecond, b(5))
+
+		b = Fixed(100*time.Millisecond, 0.5)
+		for i := 1; i < 100; i++ {
+			if delay := b(i); delay < 50*time.Millisecond || delay > 100*time.Millisecond {
+				t.Fatalf("unexpected delay: %s", delay)
+			}
+		}
+	})
+
+	t.Run("exponential", func(t *testing.T) {
+		b := Exponential(100*time.Millisecond, time.Second, 0)
+		assert.Equal(t, 100*time.Millisecond, b(1))
+		assert.Equal(t, 200*time.Millisecond, b(2))
+		assert.Equal(t, 800*time.Millisecond, b(4))
+		assert.Equal(t, time.Second, b(5))
+		assert.Equal(t, time.Second, b(100))
+
+		b = Exponential(time.Second, 0, 0)
+		assert.True(t, b(100) > 0)
+	})
+}
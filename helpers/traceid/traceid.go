@@ -0,0 +1,50 @@
+package traceid
+
+import "context"
+
+// Header of request with trace ID
+const Header = "X-Ray-Trace-ID"
+
+// key is a context key of trace ID
+type key struct{}
+
+// Error annotated with trace ID of the request
+type Error struct {
+	ID  string
+	Err error
+}
+
+// With returns copy of ctx with trace ID
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// From returns trace ID of ctx, empty when it's not set
+func From(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
+
+// Wrap annotates err with trace ID of ctx,
+// err is returned as is when it's nil or ctx hasn't trace ID
+func Wrap(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if id := From(ctx); id != "" {
+		return &Error{ID: id, Err: err}
+	}
+
+	return err
+}
+
+// Error returns message with trace ID
+func (e *Error) Error() string {
+	return e.Err.Error() + " (" + Header + ": " + e.ID + ")"
+}
+
+// Cause returns original error
+func (e *Error) Cause() error {
+	return e.Err
+}
@@ -0,0 +1,28 @@
+package traceid
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTraceID(t *testing.T) {
+	var (
+		errBad = errors.New("bad")
+		ctx    = With(context.Background(), "id")
+	)
+
+	assert.Equal(t, "", From(context.Background()))
+	assert.Equal(t, "id", From(ctx))
+
+	assert.Nil(t, Wrap(ctx, nil))
+	assert.Equal(t, errBad, Wrap(context.Background(), errBad))
+
+	err := Wrap(ctx, errBad)
+	if assert.IsType(t, &Error{}, err) {
+		assert.Equal(t, errBad, err.(*Error).Cause())
+		assert.Equal(t, "bad (X-Ray-Trace-ID: id)", err.Error())
+	}
+}
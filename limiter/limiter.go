@@ -0,0 +1,113 @@
+package limiter
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers"
+	"github.com/cryptopay-dev/yaga/logger"
+	"github.com/go-redis/redis"
+)
+
+// ErrRedis issued when Redis failed, the cause is logged by Limiter
+var ErrRedis = errors.New("limiter failed: redis error")
+
+// takeCall records call ARGV[1] of KEYS[1] at time of Redis server, when count
+// of calls during window ARGV[2] (ms) is less than limit ARGV[3].
+// Returns 0 when call is allowed, otherwise ms to wait until the oldest call leaves window.
+var takeCall = redis.NewScript(`
+redis.replicate_commands()
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local window = tonumber(ARGV[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+
+if redis.call('ZCARD', KEYS[1]) < tonumber(ARGV[3]) then
+	redis.call('ZADD', KEYS[1], now, ARGV[1])
+	redis.call('PEXPIRE', KEYS[1], window)
+	return 0
+end
+
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+return math.max(tonumber(oldest[2]) + window - now, 1)
+`)
+
+// Limiter is a sliding-window rate limiter of calls across processes
+type Limiter interface {
+	// Allow reports whether call of key is allowed now, allowed call is counted
+	Allow(key string) (bool, error)
+	// Wait blocks until call of key is allowed, returns ctx.Err() when ctx is done
+	Wait(key string) error
+	// WithContext returns Limiter, which stops waiting when ctx is done
+	WithContext(ctx context.Context) Limiter
+}
+
+type limiter struct {
+	redis   *redis.Client
+	logger  logger.Logger
+	options Options
+	ctx     context.Context
+}
+
+// New creates instance of Limiter
+func New(opts ...Option) Limiter {
+	var options = newOptions(opts...)
+	return &limiter{
+		redis:   options.Redis,
+		logger:  options.Logger,
+		options: options,
+		ctx:     context.Background(),
+	}
+}
+
+// WithContext returns copy of limiter bound to ctx
+func (l *limiter) WithContext(ctx context.Context) Limiter {
+	if ctx == nil {
+		panic("nil context")
+	}
+
+	c := *l
+	c.redis = l.redis.WithContext(ctx)
+	c.ctx = ctx
+	return &c
+}
+
+// take tries to record call, returns delay until next try or zero when call is allowed
+func (l *limiter) take(key string) (time.Duration, error) {
+	ms, err := takeCall.Run(l.redis, []string{key},
+		helpers.NewUUID(), int64(l.options.Window/time.Millisecond), l.options.Limit).Int64()
+	if err != nil {
+		l.logger.Warn("Limiter error", err)
+		return 0, ErrRedis
+	}
+
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// Allow reports whether call of key is allowed now
+func (l *limiter) Allow(key string) (bool, error) {
+	delay, err := l.take(key)
+	return err == nil && delay == 0, err
+}
+
+// Wait blocks until call of key is allowed
+func (l *limiter) Wait(key string) error {
+	for {
+		delay, err := l.take(key)
+		if err != nil || delay == 0 {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-l.ctx.Done():
+			timer.Stop()
+			return l.ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package limiter
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/logger/nop"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+const testKey = "test:limiter"
+
+// defaultLimiter connects to TEST_REDIS_ADDR, test is skipped when Redis is unavailable
+func defaultLimiter(t *testing.T, opts ...Option) Limiter {
+	client := redis.NewClient(&redis.Options{
+		Addr:        os.Getenv("TEST_REDIS_ADDR"),
+		DialTimeout: time.Second,
+	})
+
+	if err := client.Ping().Err(); err != nil {
+		client.Close()
+		t.Skipf("redis is unavailable: %v", err)
+	}
+
+	return New(append([]Option{
+		Logger(nop.New()),
+		Redis(client),
+	}, opts...)...)
+}
+
+func TestAllow(t *testing.T) {
+	l := defaultLimiter(t, Limit(3), Window(100*time.Millisecond))
+
+	for i := 0; i < 3; i++ {
+		if ok, err := l.Allow(testKey); !assert.NoError(t, err) || !assert.True(t, ok) {
+			t.FailNow()
+		}
+	}
+
+	if ok, err := l.Allow(testKey); !assert.NoError(t, err) || !assert.False(t, ok) {
+		t.FailNow()
+	}
+
+	// calls leave window:
+	time.Sleep(150 * time.Millisecond)
+
+	if ok, err := l.Allow(testKey); !assert.NoError(t, err) || !assert.True(t, ok) {
+		t.FailNow()
+	}
+}
+
+func TestWait(t *testing.T) {
+	l := defaultLimiter(t, Limit(1), Window(50*time.Millisecond))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(testKey + ":wait"); !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, l.WithContext(ctx).Wait(testKey+":wait"))
+}
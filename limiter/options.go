@@ -0,0 +1,81 @@
+package limiter
+
+import (
+	"time"
+
+	"github.com/cryptopay-dev/yaga/logger"
+	"github.com/cryptopay-dev/yaga/logger/nop"
+	"github.com/go-redis/redis"
+)
+
+const (
+	// DefaultLimit used when Options.Limit is not set
+	DefaultLimit = 1
+
+	// DefaultWindow used when Options.Window is not set
+	DefaultWindow = time.Second
+)
+
+// Options for creating Limiter instance
+type Options struct {
+	Redis *redis.Client
+	// Logger of Redis errors, nop-logger by default
+	Logger logger.Logger
+	// Limit of calls of key per Window
+	Limit int
+	// Window of sliding-window limiter
+	Window time.Duration
+}
+
+// Option closure
+type Option func(*Options)
+
+// newOptions converts slice of closures to Options-field
+func newOptions(opts ...Option) Options {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.Limit <= 0 {
+		options.Limit = DefaultLimit
+	}
+
+	if options.Window < time.Millisecond {
+		options.Window = DefaultWindow
+	}
+
+	if options.Logger == nil {
+		options.Logger = nop.New()
+	}
+
+	return options
+}
+
+// Redis closure to set field in Options
+func Redis(r *redis.Client) Option {
+	return func(o *Options) {
+		o.Redis = r
+	}
+}
+
+// Logger closure to set field in Options
+func Logger(l logger.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// Limit closure to set field in Options
+func Limit(n int) Option {
+	return func(o *Options) {
+		o.Limit = n
+	}
+}
+
+// Window closure to set field in Options
+func Window(w time.Duration) Option {
+	return func(o *Options) {
+		o.Window = w
+	}
+}
@@ -0,0 +1,236 @@
+package locker
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/go-pg/pg"
+)
+
+// advisoryBackend holds locks as Postgres advisory locks. Lock is held by transaction,
+// so it is released by Postgres when connection is lost.
+// Leases of this process are tracked, to expire them after ttl
+// and to obtain locks of the same owner again. Mutex guards only
+// the leases, queries are sent without it, because a query can wait
+// for a free connection of the pool.
+type advisoryBackend struct {
+	db     *pg.DB
+	mu     *sync.Mutex
+	leases map[string]*advisoryLock
+}
+
+// advisoryLock is a transaction holding advisory lock,
+// queries of the transaction are serialized by its mutex
+type advisoryLock struct {
+	mu       sync.Mutex
+	tx       *pg.Tx
+	owner    string
+	count    int
+	timer    *time.Timer
+	deadline time.Time
+}
+
+// advisoryLease of the lock in Postgres
+type advisoryLease struct {
+	backend *advisoryBackend
+	key     string
+	owner   string
+	ttl     time.Duration
+	lock    *advisoryLock
+}
+
+func newAdvisoryBackend(db *pg.DB) *advisoryBackend {
+	return &advisoryBackend{
+		db:     db,
+		mu:     new(sync.Mutex),
+		leases: make(map[string]*advisoryLock),
+	}
+}
+
+// advisoryKey hashes string key to int64 key of advisory lock
+func advisoryKey(key string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return int64(h.Sum64())
+}
+
+func (b *advisoryBackend) lease(key, owner string, ttl time.Duration) lease {
+	return &advisoryLease{
+		backend: b,
+		key:     key,
+		owner:   owner,
+		ttl:     ttl,
+	}
+}
+
+func (b *advisoryBackend) withContext(ctx context.Context) backend {
+	c := *b
+	c.db = b.db.WithContext(ctx)
+	return &c
+}
+
+func (b *advisoryBackend) failure() error {
+	return ErrDatabase
+}
+
+// expire releases lock, when it wasn't refreshed for ttl
+func (b *advisoryBackend) expire(key string, lock *advisoryLock) {
+	b.mu.Lock()
+	if b.leases[key] != lock || time.Now().Before(lock.deadline) {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.leases, key)
+	b.mu.Unlock()
+
+	lock.rollback()
+}
+
+// rollback transaction of lock, which releases advisory lock
+func (lock *advisoryLock) rollback() error {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+
+	return lock.tx.Rollback()
+}
+
+// held returns lock of lease, when it is still held, should be called under lock
+func (l *advisoryLease) held() bool {
+	return l.lock != nil && l.backend.leases[l.key] == l.lock
+}
+
+// prolong deadline of lock for ttl of lease, deadline is only extended
+func (l *advisoryLease) prolong() {
+	if deadline := time.Now().Add(l.ttl); deadline.After(l.lock.deadline) {
+		l.lock.deadline = deadline
+		l.lock.timer.Reset(l.ttl)
+	}
+}
+
+// reenter the lock held by the same owner, reports whether the key is free
+func (l *advisoryLease) reenter() (ok, free bool) {
+	l.backend.mu.Lock()
+	defer l.backend.mu.Unlock()
+
+	lock, found := l.backend.leases[l.key]
+	if !found {
+		return false, true
+	}
+
+	if lock.owner != l.owner {
+		return false, false
+	}
+
+	lock.count++
+	l.lock = lock
+	l.prolong()
+	return true, false
+}
+
+func (l *advisoryLease) obtain() (bool, error) {
+	if ok, free := l.reenter(); !free {
+		return ok, nil
+	}
+
+	tx, err := l.backend.db.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	var ok bool
+	if _, err = tx.QueryOne(pg.Scan(&ok), "SELECT pg_try_advisory_xact_lock(?)", advisoryKey(l.key)); err != nil || !ok {
+		tx.Rollback()
+		return false, err
+	}
+
+	lock := &advisoryLock{
+		tx:       tx,
+		owner:    l.owner,
+		count:    1,
+		deadline: time.Now().Add(l.ttl),
+	}
+
+	l.backend.mu.Lock()
+	defer l.backend.mu.Unlock()
+
+	// advisory lock is held by this process only once,
+	// so the key can't be taken while the query was sent:
+	l.backend.leases[l.key] = lock
+	l.lock = lock
+
+	lock.timer = time.AfterFunc(l.ttl, func() {
+		l.backend.expire(l.key, lock)
+	})
+
+	return true, nil
+}
+
+// current lock of lease, nil when it is lost
+func (l *advisoryLease) current() *advisoryLock {
+	l.backend.mu.Lock()
+	defer l.backend.mu.Unlock()
+
+	if !l.held() {
+		return nil
+	}
+
+	return l.lock
+}
+
+// refresh checks connection of transaction and prolongs lease
+func (l *advisoryLease) refresh() (bool, error) {
+	lock := l.current()
+	if lock == nil {
+		return false, nil
+	}
+
+	lock.mu.Lock()
+	_, err := lock.tx.Exec("SELECT 1")
+	lock.mu.Unlock()
+
+	l.backend.mu.Lock()
+	defer l.backend.mu.Unlock()
+
+	if !l.held() {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	l.prolong()
+	return true, nil
+}
+
+// release decrements count of lock, transaction is finished when count reaches zero
+func (l *advisoryLease) release() (bool, error) {
+	l.backend.mu.Lock()
+
+	if !l.held() {
+		l.backend.mu.Unlock()
+		return false, nil
+	}
+
+	if l.lock.count--; l.lock.count > 0 {
+		l.backend.mu.Unlock()
+		return true, nil
+	}
+
+	delete(l.backend.leases, l.key)
+	l.lock.timer.Stop()
+	l.backend.mu.Unlock()
+
+	return true, l.lock.rollback()
+}
+
+func (l *advisoryLease) remaining() (time.Duration, bool, error) {
+	l.backend.mu.Lock()
+	defer l.backend.mu.Unlock()
+
+	if !l.held() {
+		return 0, false, nil
+	}
+
+	return time.Until(l.lock.deadline), true, nil
+}
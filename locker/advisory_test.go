@@ -0,0 +1,127 @@
+package locker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers/testdb"
+	"github.com/cryptopay-dev/yaga/logger/nop"
+	"github.com/stretchr/testify/assert"
+)
+
+func advisoryLocker() Locker {
+	return New(
+		Logger(nop.New()),
+		DB(testdb.GetTestDB().DB),
+		RetryCount(-1),
+	)
+}
+
+func TestAdvisoryKey(t *testing.T) {
+	assert.Equal(t, advisoryKey(testKey), advisoryKey(testKey))
+	assert.NotEqual(t, advisoryKey(testKey), advisoryKey(testKey+":other"))
+}
+
+func TestAdvisory(t *testing.T) {
+	var (
+		ran bool
+		l   = advisoryLocker()
+	)
+
+	err := l.Run(testKey, time.Second, func(ctx context.Context) {
+		ran = true
+
+		// another process can't obtain the lock:
+		other := New(Logger(nop.New()), DB(testdb.GetTestDB().DB), RetryCount(-1))
+		assert.Equal(t, ErrNotObtained, other.TryRun(testKey, time.Second, func(context.Context) {}))
+	})
+
+	if !assert.NoError(t, err) || !assert.True(t, ran) {
+		t.FailNow()
+	}
+
+	t.Run("handle", func(t *testing.T) {
+		h, err := l.Obtain(testKey, time.Minute)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		if ttl, err := h.TTL(); !assert.NoError(t, err) || !assert.True(t, ttl > 0 && ttl <= time.Minute) {
+			t.FailNow()
+		}
+
+		assert.NoError(t, h.Refresh())
+		assert.NoError(t, h.Release())
+		assert.Equal(t, ErrLost, h.Release())
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		h, err := l.Obtain(testKey, 20*time.Millisecond)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		time.Sleep(50 * time.Millisecond)
+
+		assert.Equal(t, ErrLost, h.Refresh())
+		assert.NoError(t, l.TryRun(testKey, time.Second, func(context.Context) {}))
+	})
+
+	t.Run("reentrant", func(t *testing.T) {
+		owner := l.WithContext(WithOwner(context.Background(), "owner"))
+
+		err := owner.Run(testKey, time.Second, func(ctx context.Context) {
+			assert.NoError(t, owner.WithContext(ctx).TryRun(testKey, time.Second, func(context.Context) {}))
+			assert.Equal(t, ErrNotObtained, l.TryRun(testKey, time.Second, func(context.Context) {}))
+		})
+		assert.NoError(t, err)
+	})
+	t.Run("more locks than connections", func(t *testing.T) {
+		// every lock holds a connection, pool of test database has 2 of them:
+		var handles []Handle
+		for i := 0; i < testdb.GetTestDB().DB.Options().PoolSize; i++ {
+			h, err := l.Obtain(fmt.Sprintf("%s:%d", testKey, i), time.Minute)
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			handles = append(handles, h)
+		}
+
+		obtained := make(chan error, 1)
+		go func() {
+			h, err := l.Obtain(testKey+":last", time.Minute)
+			if err == nil {
+				err = h.Release()
+			}
+			obtained <- err
+		}()
+
+		// let obtain wait for connection:
+		time.Sleep(50 * time.Millisecond)
+
+		// held locks are refreshed and released while obtain waits for connection:
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for _, h := range handles {
+				assert.NoError(t, h.Refresh())
+				assert.NoError(t, h.Release())
+			}
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("held locks are blocked by obtain")
+		}
+
+		select {
+		case err := <-obtained:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("lock isn't obtained after release")
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package locker
+
+import (
+	"context"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers"
+)
+
+// Handle of obtained lock
+type Handle interface {
+	// Refresh prolongs the lease for its ttl, returns ErrLost when lease is lost
+	Refresh() error
+	// Release the lock, returns ErrLost when lease was already lost
+	Release() error
+	// TTL returns remaining time of the lease, returns ErrLost when lease is lost
+	TTL() (time.Duration, error)
+}
+
+// TryObtainer is a Locker, which obtains the lock without waiting
+type TryObtainer interface {
+	// TryObtain the lock for ttl without waiting, returns ErrNotObtained at once
+	// when the lock is held by another process
+	TryObtain(key string, ttl time.Duration) (Handle, error)
+}
+
+// TryObtain the lock of l without waiting, when l is a TryObtainer,
+// otherwise the lock is obtained by l.Obtain
+func TryObtain(l Locker, key string, ttl time.Duration) (Handle, error) {
+	if t, ok := l.(TryObtainer); ok {
+		return t.TryObtain(key, ttl)
+	}
+
+	return l.Obtain(key, ttl)
+}
+
+// ownerKey is a context key of owner token
+type ownerKey struct{}
+
+// WithOwner returns copy of ctx with owner token. Locker bound to such ctx
+// obtains reentrant locks: the lock held by the same owner is obtained again
+// without waiting, and is released when every obtain is released.
+func WithOwner(ctx context.Context, owner string) context.Context {
+	return context.WithValue(ctx, ownerKey{}, owner)
+}
+
+// Owner returns owner token from ctx, empty when it's not set
+func Owner(ctx context.Context) string {
+	owner, _ := ctx.Value(ownerKey{}).(string)
+	return owner
+}
+
+// handle of lease obtained by Lock
+type handle struct {
+	lock  *Lock
+	lease lease
+}
+
+// Obtain the lock, waits for it when it is held by another process.
+// Unlike Run, lease is not renewed automatically and should be refreshed
+// by Handle.Refresh before ttl is exceeded.
+func (l *Lock) Obtain(key string, ttl time.Duration) (Handle, error) {
+	ls, err := l.obtain(key, ttl, l.options.RetryCount)
+	if err != nil {
+		return nil, err
+	}
+
+	return &handle{lock: l, lease: ls}, nil
+}
+
+// TryObtain the lock without retries.
+func (l *Lock) TryObtain(key string, ttl time.Duration) (Handle, error) {
+	ls, err := l.obtain(key, ttl, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &handle{lock: l, lease: ls}, nil
+}
+
+// newLease creates lease of key, owned by owner from context or by unique token
+func (l *Lock) newLease(key string, ttl time.Duration) lease {
+	owner := Owner(l.ctx)
+	if owner == "" {
+		owner = helpers.NewUUID()
+	}
+
+	return l.backend.lease(key, owner, ttl)
+}
+
+// Refresh prolongs the lease for its ttl
+func (h *handle) Refresh() error {
+	if ok, err := h.lease.refresh(); err != nil {
+		return h.lock.backendError(err)
+	} else if !ok {
+		return ErrLost
+	}
+	return nil
+}
+
+// Release the lock
+func (h *handle) Release() error {
+	if ok, err := h.lease.release(); err != nil {
+		return h.lock.backendError(err)
+	} else if !ok {
+		return ErrLost
+	}
+	return nil
+}
+
+// TTL returns remaining time of the lease
+func (h *handle) TTL() (time.Duration, error) {
+	ttl, ok, err := h.lease.remaining()
+	if err != nil {
+		return 0, h.lock.backendError(err)
+	} else if !ok {
+		return 0, ErrLost
+	}
+	return ttl, nil
+}
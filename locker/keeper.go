@@ -0,0 +1,100 @@
+package locker
+
+import (
+	"context"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/logger"
+	"go.uber.org/atomic"
+)
+
+// Keeper keeps a lease in backend while handler runs, it is shared
+// by Locker and other primitives built on leases, like semaphore
+type Keeper struct {
+	Logger logger.Logger
+	// Name of primitive in logged errors
+	Name string
+	// Failure is returned instead of backend errors, the cause is logged
+	Failure error
+}
+
+// Error logs the cause and returns Failure
+func (k Keeper) Error(err error) error {
+	k.Logger.Warn(k.Name+" error", err)
+	return k.Failure
+}
+
+// Run runs handler, while watchdog calls refresh every third of ttl, handler
+// context is cancelled when the lease is lost. Release is called after handler,
+// even when it panics, ErrLost is returned when the lease was lost before.
+func (k Keeper) Run(ctx context.Context, ttl time.Duration, refresh, release func() (bool, error), handler func(ctx context.Context)) error {
+	var (
+		done         = make(chan struct{})
+		lost         = atomic.NewBool(false)
+		hctx, cancel = context.WithCancel(ctx)
+	)
+
+	go k.watchdog(ttl, refresh, done, func() {
+		lost.Store(true)
+		cancel()
+	})
+
+	stop := func() {
+		close(done)
+		cancel()
+	}
+
+	// lease of panicked handler is released before the panic goes on:
+	defer func() {
+		if rVal := recover(); rVal != nil {
+			stop()
+			release()
+			panic(rVal)
+		}
+	}()
+
+	handler(hctx)
+	stop()
+
+	if lost.Load() {
+		return ErrLost
+	}
+
+	if ok, err := release(); err != nil {
+		return k.Error(err)
+	} else if !ok {
+		return ErrLost
+	}
+
+	return nil
+}
+
+// watchdog calls refresh every third of ttl until done is closed,
+// lost is called when the lease is taken by another process or is expired
+// because backend is unavailable
+func (k Keeper) watchdog(ttl time.Duration, refresh func() (bool, error), done <-chan struct{}, lost func()) {
+	var (
+		ticker  = time.NewTicker(ttl / 3)
+		renewed = time.Now()
+	)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			ok, err := refresh()
+			switch {
+			case err != nil && now.Sub(renewed) < ttl:
+				k.Logger.Warn(k.Name+" refresh error", err)
+				continue
+			case err != nil || !ok:
+				lost()
+				return
+			}
+			renewed = now
+		}
+	}
+}
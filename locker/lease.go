@@ -0,0 +1,162 @@
+package locker
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Lease is stored in Redis as a hash of owner token and count of nested obtains.
+// Key of other type is a lock of previous versions, which stored owner token as
+// a string: it's treated as held by another owner until it expires, so old and
+// new versions exclude each other while they are deployed side by side.
+var (
+	// obtainLease sets KEYS[1] to owner ARGV[1] with ttl ARGV[2] (in ms),
+	// when lease is already held by the same owner, count is incremented
+	// and ttl is only extended
+	obtainLease = redis.NewScript(`
+local kind = redis.call('TYPE', KEYS[1]).ok
+
+if kind == 'none' then
+	redis.call('HMSET', KEYS[1], 'owner', ARGV[1], 'count', 1)
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+
+if kind ~= 'hash' or redis.call('HGET', KEYS[1], 'owner') ~= ARGV[1] then
+	return 0
+end
+
+redis.call('HINCRBY', KEYS[1], 'count', 1)
+
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+	// refreshLease prolongs KEYS[1] up to ARGV[2] ms when it is still held by owner ARGV[1],
+	// ttl left by nested lease with longer ttl isn't shortened
+	refreshLease = redis.NewScript(`
+if redis.call('TYPE', KEYS[1]).ok ~= 'hash' or redis.call('HGET', KEYS[1], 'owner') ~= ARGV[1] then
+	return 0
+end
+
+if redis.call('PTTL', KEYS[1]) < tonumber(ARGV[2]) then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 1
+`)
+
+	// releaseLease decrements count of KEYS[1] when it is still held by owner ARGV[1],
+	// lease is removed when count reaches zero
+	releaseLease = redis.NewScript(`
+if redis.call('TYPE', KEYS[1]).ok ~= 'hash' or redis.call('HGET', KEYS[1], 'owner') ~= ARGV[1] then
+	return 0
+end
+
+if redis.call('HINCRBY', KEYS[1], 'count', -1) <= 0 then
+	redis.call('DEL', KEYS[1])
+end
+return 1
+`)
+
+	// ttlLease returns ttl of KEYS[1] in ms when it is still held by owner ARGV[1], otherwise -2
+	ttlLease = redis.NewScript(`
+if redis.call('TYPE', KEYS[1]).ok == 'hash' and redis.call('HGET', KEYS[1], 'owner') == ARGV[1] then
+	return redis.call('PTTL', KEYS[1])
+end
+return -2
+`)
+)
+
+// lease of the lock in backend
+type lease interface {
+	// obtain tries to hold the lock once, reports whether lease is obtained
+	obtain() (bool, error)
+	// refresh prolongs lease for its ttl, reports false when lease is lost
+	refresh() (bool, error)
+	// release lease, reports false when lease was already lost
+	release() (bool, error)
+	// remaining ttl of lease, reports false when lease is lost
+	remaining() (time.Duration, bool, error)
+}
+
+// backend of Locker, which creates leases
+type backend interface {
+	lease(key, owner string, ttl time.Duration) lease
+	withContext(ctx context.Context) backend
+	// failure is an error returned instead of backend errors
+	failure() error
+}
+
+// redisBackend holds locks in Redis
+type redisBackend struct {
+	redis *redis.Client
+}
+
+// redisLease of the lock in Redis, held while key contains owner token
+type redisLease struct {
+	redis *redis.Client
+	key   string
+	owner string
+	ttl   time.Duration
+}
+
+func (b *redisBackend) lease(key, owner string, ttl time.Duration) lease {
+	return &redisLease{
+		redis: b.redis,
+		key:   key,
+		owner: owner,
+		ttl:   ttl,
+	}
+}
+
+func (b *redisBackend) withContext(ctx context.Context) backend {
+	return &redisBackend{redis: b.redis.WithContext(ctx)}
+}
+
+func (b *redisBackend) failure() error {
+	return ErrRedis
+}
+
+// milliseconds of ttl, sub-millisecond ttl is rounded up
+func milliseconds(ttl time.Duration) int64 {
+	if ms := int64(ttl / time.Millisecond); ms > 0 {
+		return ms
+	}
+	return 1
+}
+
+// obtain tries to hold key once, reports whether lease is obtained
+func (l *redisLease) obtain() (bool, error) {
+	status, err := obtainLease.Run(l.redis, []string{l.key}, l.owner, milliseconds(l.ttl)).Int64()
+	return status == 1, err
+}
+
+// refresh prolongs lease for ttl, reports false when lease is lost
+func (l *redisLease) refresh() (bool, error) {
+	status, err := refreshLease.Run(l.redis, []string{l.key}, l.owner, milliseconds(l.ttl)).Int64()
+	return status == 1, err
+}
+
+// release lease, reports false when lease was already lost
+func (l *redisLease) release() (bool, error) {
+	status, err := releaseLease.Run(l.redis, []string{l.key}, l.owner).Int64()
+	return status == 1, err
+}
+
+// remaining ttl of lease, reports false when lease is lost
+func (l *redisLease) remaining() (time.Duration, bool, error) {
+	ms, err := ttlLease.Run(l.redis, []string{l.key}, l.owner).Int64()
+	if err != nil || ms == -2 {
+		return 0, false, err
+	}
+
+	if ms < 0 {
+		return 0, true, nil
+	}
+
+	return time.Duration(ms) * time.Millisecond, true, nil
+}
@@ -1,46 +1,176 @@
 package locker
 
 import (
+	"context"
+	"errors"
 	"time"
 
-	"github.com/bsm/redis-lock"
+	"github.com/cryptopay-dev/yaga/helpers/traceid"
 	"github.com/cryptopay-dev/yaga/logger"
-	"github.com/go-redis/redis"
 )
 
-// Locker interface to abstract bsm/redis-lock
+var (
+	// ErrNotObtained issued when lock is held by another process
+	// and wasn't released while Locker was waiting for it
+	ErrNotObtained = errors.New("lock not obtained")
+
+	// ErrLost issued when lease of the lock was lost before handler finished
+	ErrLost = errors.New("lock lost during execution")
+
+	// ErrRedis issued when Redis failed, the cause is logged by Locker
+	ErrRedis = errors.New("lock failed: redis error")
+
+	// ErrDatabase issued when Postgres failed, the cause is logged by Locker
+	ErrDatabase = errors.New("lock failed: database error")
+
+	// ErrInvalidTimeout issued when lease timeout is less than a millisecond
+	ErrInvalidTimeout = errors.New("invalid lock timeout")
+)
+
+// Locker interface of distributed lock
 type Locker interface {
-	Run(key string, timeout time.Duration, handler func())
+	// Run runs handler with a lock, waits for the lock when it is held by another process.
+	// Timeout is a lease of the lock, which is renewed while handler runs,
+	// handler context is cancelled when the lease is lost.
+	Run(key string, timeout time.Duration, handler func(ctx context.Context)) error
+	// TryRun runs handler with a lock, returns ErrNotObtained at once
+	// when the lock is held by another process
+	TryRun(key string, timeout time.Duration, handler func(ctx context.Context)) error
+	// Obtain the lock for ttl and return its Handle to refresh and release it
+	Obtain(key string, ttl time.Duration) (Handle, error)
+	// WithContext returns Locker, which stops waiting for the lock
+	// when ctx is done and logs errors with trace ID of ctx
+	WithContext(ctx context.Context) Locker
 }
 
-// Lock struct to abstract bsm/redis-lock
+// Lock is a Locker based on Redis or Postgres advisory locks
 type Lock struct {
-	redis  *redis.Client
-	locker *lock.Options
-	logger logger.Logger
+	backend backend
+	logger  logger.Logger
+	options Options
+	ctx     context.Context
 }
 
-// New creates instance of Locker
+// New creates instance of Locker, Postgres advisory locks
+// are used when Options.DB is set instead of Options.Redis
 func New(opts ...Option) Locker {
-	var options = newOptions(opts...)
+	var (
+		options = newOptions(opts...)
+		b       backend
+	)
+
+	if options.Redis == nil && options.DB != nil {
+		b = newAdvisoryBackend(options.DB)
+	} else {
+		b = &redisBackend{redis: options.Redis}
+	}
+
 	return &Lock{
-		redis:  options.Redis,
-		logger: options.Logger,
-		locker: &lock.Options{
-			RetryCount: 10,
-			RetryDelay: 100 * time.Millisecond,
-		},
+		backend: b,
+		logger:  options.Logger,
+		options: options,
+		ctx:     context.Background(),
 	}
 }
 
-// Run runs a callback handler with a Redis lock.
-func (l *Lock) Run(key string, timeout time.Duration, handler func()) {
-	opts := &lock.Options{
-		RetryCount:  l.locker.RetryCount,
-		RetryDelay:  l.locker.RetryDelay,
-		LockTimeout: timeout,
+// WithContext returns copy of Lock bound to ctx
+func (l *Lock) WithContext(ctx context.Context) Locker {
+	if ctx == nil {
+		panic("nil context")
 	}
-	if err := lock.Run(l.redis, key, opts, handler); err != nil {
-		l.logger.Warn("Locker error", err)
+
+	c := *l
+	c.backend = l.backend.withContext(ctx)
+	c.ctx = ctx
+
+	if id := traceid.From(ctx); id != "" {
+		c.logger = l.logger.WithContext(map[string]interface{}{
+			traceid.Header: id,
+		})
+	}
+
+	return &c
+}
+
+// Run runs a callback handler with a Redis lock. The lease of the lock
+// is renewed while handler runs, handler context is cancelled when lease is lost.
+func (l *Lock) Run(key string, timeout time.Duration, handler func(ctx context.Context)) error {
+	return l.run(key, timeout, l.options.RetryCount, handler)
+}
+
+// TryRun runs a callback handler with a Redis lock without retries.
+func (l *Lock) TryRun(key string, timeout time.Duration, handler func(ctx context.Context)) error {
+	return l.run(key, timeout, 0, handler)
+}
+
+// obtain the lease, retrying with backoff until retries
+// are exhausted, wait timeout is exceeded or ctx is done
+func (l *Lock) obtain(key string, ttl time.Duration, retries int) (lease, error) {
+	if ttl < time.Millisecond {
+		return nil, ErrInvalidTimeout
 	}
+
+	var (
+		ls  = l.newLease(key, ttl)
+		ctx = l.ctx
+	)
+
+	if l.options.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.options.WaitTimeout)
+		defer cancel()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := l.ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if ok, err := ls.obtain(); err != nil {
+			return nil, l.backendError(err)
+		} else if ok {
+			return ls, nil
+		}
+
+		if attempt >= retries {
+			return nil, ErrNotObtained
+		}
+
+		delay := time.NewTimer(l.options.Backoff(attempt + 1))
+
+		select {
+		case <-ctx.Done():
+			delay.Stop()
+			// wait timeout isn't an error of caller's ctx:
+			if err := l.ctx.Err(); err != nil {
+				return nil, err
+			}
+			return nil, ErrNotObtained
+		case <-delay.C:
+		}
+	}
+}
+
+// keeper of leases of the locker
+func (l *Lock) keeper() Keeper {
+	return Keeper{
+		Logger:  l.logger,
+		Name:    "Locker",
+		Failure: l.backend.failure(),
+	}
+}
+
+// run obtains the lock and runs handler, while keeper renews the lease
+func (l *Lock) run(key string, timeout time.Duration, retries int, handler func(ctx context.Context)) error {
+	ls, err := l.obtain(key, timeout, retries)
+	if err != nil {
+		return err
+	}
+
+	return l.keeper().Run(l.ctx, timeout, ls.refresh, ls.release, handler)
+}
+
+// backendError logs the cause and returns failure error of backend
+func (l *Lock) backendError(err error) error {
+	return l.keeper().Error(err)
 }
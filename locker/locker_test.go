@@ -0,0 +1,331 @@
+package locker
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers/backoff"
+	"github.com/cryptopay-dev/yaga/helpers/traceid"
+	"github.com/cryptopay-dev/yaga/logger/nop"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+const testKey = "test:locker"
+
+// defaultLocker connects to TEST_REDIS_ADDR, test is skipped when Redis is unavailable
+func defaultLocker(t *testing.T, opts ...Option) Locker {
+	client := redis.NewClient(&redis.Options{
+		Addr:        os.Getenv("TEST_REDIS_ADDR"),
+		DialTimeout: time.Second,
+	})
+
+	if err := client.Ping().Err(); err != nil {
+		client.Close()
+		t.Skipf("redis is unavailable: %v", err)
+	}
+
+	return New(append([]Option{
+		Logger(nop.New()),
+		Redis(client),
+	}, opts...)...)
+}
+
+func TestRun(t *testing.T) {
+	var ran bool
+
+	if err := defaultLocker(t).Run(testKey, time.Second, func(context.Context) {
+		ran = true
+	}); !assert.NoError(t, err) || !assert.True(t, ran) {
+		t.FailNow()
+	}
+
+	t.Run("renewed", func(t *testing.T) {
+		l := defaultLocker(t)
+
+		err := l.Run(testKey, 30*time.Millisecond, func(ctx context.Context) {
+			time.Sleep(100 * time.Millisecond)
+
+			// lease is still held:
+			assert.Equal(t, ErrNotObtained, l.TryRun(testKey, time.Second, func(context.Context) {}))
+			assert.NoError(t, ctx.Err())
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("lost", func(t *testing.T) {
+		client := redis.NewClient(&redis.Options{Addr: os.Getenv("TEST_REDIS_ADDR")})
+		defer client.Close()
+
+		err := defaultLocker(t).Run(testKey, 30*time.Millisecond, func(ctx context.Context) {
+			client.Del(testKey)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+				t.Error("context is not cancelled")
+			}
+		})
+		assert.Equal(t, ErrLost, err)
+	})
+
+	t.Run("redis failure", func(t *testing.T) {
+		client := redis.NewClient(&redis.Options{Addr: os.Getenv("TEST_REDIS_ADDR")})
+		client.Close()
+
+		err := New(Logger(nop.New()), Redis(client)).Run(testKey, time.Second, func(context.Context) {})
+		assert.Equal(t, ErrRedis, err)
+
+		// errors are logged by nop-logger by default:
+		err = New(Redis(client)).Run(testKey, time.Second, func(context.Context) {})
+		assert.Equal(t, ErrRedis, err)
+	})
+}
+
+func TestPanic(t *testing.T) {
+	l := defaultLocker(t)
+
+	assert.Panics(t, func() {
+		l.Run(testKey, 30*time.Millisecond, func(context.Context) {
+			panic("handler panic")
+		})
+	})
+
+	// lease of panicked handler is released and isn't renewed:
+	assert.NoError(t, l.TryRun(testKey, time.Second, func(context.Context) {}))
+}
+
+func TestTryRun(t *testing.T) {
+	var (
+		l       = defaultLocker(t)
+		started = make(chan struct{})
+		release = make(chan struct{})
+		done    = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+		l.Run(testKey, time.Second, func(context.Context) {
+			close(started)
+			<-release
+		})
+	}()
+
+	select {
+	case <-started:
+	case <-done:
+		assert.FailNow(t, "Handler isn't started")
+	}
+
+	var (
+		ran   bool
+		start = time.Now()
+	)
+
+	err := l.TryRun(testKey, time.Second, func(context.Context) {
+		ran = true
+	})
+
+	assert.Equal(t, ErrNotObtained, err)
+	assert.False(t, ran)
+	assert.True(t, time.Since(start) < 50*time.Millisecond)
+
+	close(release)
+	<-done
+}
+
+func TestWithContext(t *testing.T) {
+	var (
+		l       = defaultLocker(t)
+		started = make(chan struct{})
+		release = make(chan struct{})
+		done    = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+		l.Run(testKey, time.Second, func(context.Context) {
+			close(started)
+			<-release
+		})
+	}()
+
+	select {
+	case <-started:
+	case <-done:
+		assert.FailNow(t, "Handler isn't started")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var (
+		ran   bool
+		start = time.Now()
+	)
+
+	// default retries wait for a second, but context is done earlier:
+	err := l.WithContext(ctx).Run(testKey, time.Second, func(context.Context) {
+		ran = true
+	})
+
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.False(t, ran)
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+
+	canceled, stop := context.WithCancel(context.Background())
+	stop()
+
+	assert.Equal(t, context.Canceled, l.WithContext(canceled).TryRun(testKey, time.Second, func(context.Context) {}))
+
+	// logger isn't required for trace ID of the context:
+	noLogger := New(Redis(redis.NewClient(&redis.Options{Addr: os.Getenv("TEST_REDIS_ADDR")})))
+	assert.NoError(t, noLogger.WithContext(traceid.With(context.Background(), "trace")).
+		Run(testKey+":trace", time.Second, func(context.Context) {}))
+
+	close(release)
+	<-done
+}
+
+func TestWaitTimeout(t *testing.T) {
+	var (
+		l       = defaultLocker(t)
+		started = make(chan struct{})
+		release = make(chan struct{})
+		done    = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+		l.Run(testKey, time.Second, func(context.Context) {
+			close(started)
+			<-release
+		})
+	}()
+
+	select {
+	case <-started:
+	case <-done:
+		assert.FailNow(t, "Handler isn't started")
+	}
+
+	var (
+		ran   bool
+		start = time.Now()
+	)
+
+	err := defaultLocker(t,
+		RetryCount(100),
+		RetryBackoff(backoff.Fixed(10*time.Millisecond, 0)),
+		WaitTimeout(50*time.Millisecond),
+	).Run(testKey, time.Second, func(context.Context) {
+		ran = true
+	})
+
+	assert.Equal(t, ErrNotObtained, err)
+	assert.False(t, ran)
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+
+	close(release)
+	<-done
+}
+
+func TestObtain(t *testing.T) {
+	l := defaultLocker(t)
+
+	h, err := l.Obtain(testKey, time.Minute)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if _, err = l.Obtain(testKey, time.Second); !assert.Equal(t, ErrNotObtained, err) {
+		t.FailNow()
+	}
+
+	if ttl, err := h.TTL(); !assert.NoError(t, err) || !assert.True(t, ttl > 0 && ttl <= time.Minute) {
+		t.FailNow()
+	}
+
+	if err = h.Refresh(); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if err = h.Release(); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, ErrLost, h.Release())
+	assert.Equal(t, ErrLost, h.Refresh())
+
+	_, err = h.TTL()
+	assert.Equal(t, ErrLost, err)
+
+	_, err = l.Obtain(testKey, time.Microsecond)
+	assert.Equal(t, ErrInvalidTimeout, err)
+}
+
+func TestReentrant(t *testing.T) {
+	var (
+		ran   bool
+		owner = WithOwner(context.Background(), "owner")
+		l     = defaultLocker(t).WithContext(owner)
+	)
+
+	err := l.Run(testKey, time.Second, func(ctx context.Context) {
+		assert.Equal(t, "owner", Owner(ctx))
+
+		// another owner waits for the lock:
+		assert.Equal(t, ErrNotObtained, defaultLocker(t).TryRun(testKey, time.Second, func(context.Context) {}))
+
+		// nested call of the same owner obtains it again:
+		assert.NoError(t, l.WithContext(ctx).TryRun(testKey, time.Second, func(context.Context) {
+			ran = true
+		}))
+
+		// and the lock is still held after nested call:
+		h, err := defaultLocker(t).WithContext(ctx).Obtain(testKey, time.Second)
+		if assert.NoError(t, err) {
+			assert.NoError(t, h.Release())
+		}
+		assert.Equal(t, ErrNotObtained, defaultLocker(t).TryRun(testKey, time.Second, func(context.Context) {}))
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, ran)
+
+	// lock is released by the last release:
+	assert.NoError(t, defaultLocker(t).TryRun(testKey, time.Second, func(context.Context) {}))
+
+	// nested lease with shorter ttl doesn't shorten the lock:
+	outer, err := l.Obtain(testKey, time.Minute)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	inner, err := l.Obtain(testKey, time.Second)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.NoError(t, inner.Refresh())
+
+	ttl, err := outer.TTL()
+	assert.NoError(t, err)
+	assert.True(t, ttl > time.Second, "ttl %s is shortened", ttl)
+
+	assert.NoError(t, inner.Release())
+	assert.NoError(t, outer.Release())
+
+	// lock of previous versions, stored as a string, is held by another owner:
+	client := redis.NewClient(&redis.Options{Addr: os.Getenv("TEST_REDIS_ADDR")})
+	defer client.Close()
+
+	if !assert.NoError(t, client.Set(testKey, "token", time.Second).Err()) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, ErrNotObtained, l.TryRun(testKey, time.Second, func(context.Context) {}))
+	assert.NoError(t, client.Del(testKey).Err())
+}
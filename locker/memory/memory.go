@@ -0,0 +1,325 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers"
+	"github.com/cryptopay-dev/yaga/locker"
+)
+
+// Locker is an in-process locker.Locker for tests,
+// with hooks to force contention and loss of the lock
+type Locker interface {
+	locker.Locker
+	// Hold the lock by another owner until release is called,
+	// waits while the lock is held
+	Hold(key string) (release func())
+	// Lose the lock: handlers get cancelled context,
+	// handles and Run return locker.ErrLost
+	Lose(key string)
+}
+
+// entry of held lock
+type entry struct {
+	owner    string
+	count    int
+	deadline time.Time
+	timer    *time.Timer
+	// released is closed when lock is released or lost
+	released chan struct{}
+	// lost is closed when lock is expired or lost
+	lost chan struct{}
+}
+
+type store struct {
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type service struct {
+	*store
+	options Options
+	ctx     context.Context
+}
+
+// handle of lock obtained by service
+type handle struct {
+	store *store
+	key   string
+	ttl   time.Duration
+	entry *entry
+}
+
+// New creates memory-locker instance
+func New(opts ...Option) Locker {
+	return &service{
+		store:   &store{entries: make(map[string]*entry)},
+		options: newOptions(opts...),
+		ctx:     context.Background(),
+	}
+}
+
+// WithContext returns copy of Locker bound to ctx
+func (s *service) WithContext(ctx context.Context) locker.Locker {
+	if ctx == nil {
+		panic("nil context")
+	}
+
+	c := *s
+	c.ctx = ctx
+	return &c
+}
+
+// remove entry and notify waiters, should be called under lock
+func (s *store) remove(key string, e *entry, lost bool) {
+	delete(s.entries, key)
+
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+
+	if lost {
+		close(e.lost)
+	}
+	close(e.released)
+}
+
+// expire removes entry, when it wasn't refreshed for ttl
+func (s *store) expire(key string, e *entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries[key] == e && !time.Now().Before(e.deadline) {
+		s.remove(key, e, true)
+	}
+}
+
+// prolong deadline of entry for ttl, deadline is only extended,
+// zero ttl means without expiration
+func (s *store) prolong(key string, e *entry, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	deadline := time.Now().Add(ttl)
+	if deadline.Before(e.deadline) {
+		return
+	}
+
+	e.deadline = deadline
+
+	if e.timer == nil {
+		e.timer = time.AfterFunc(ttl, func() { s.expire(key, e) })
+	} else {
+		e.timer.Reset(ttl)
+	}
+}
+
+// acquire the lock for owner, waits until ctx is done when wait is set
+func (s *store) acquire(ctx context.Context, key, owner string, ttl time.Duration, wait bool) (*entry, error) {
+	for {
+		s.mu.Lock()
+
+		e, ok := s.entries[key]
+		switch {
+		case !ok:
+			e = &entry{
+				owner:    owner,
+				released: make(chan struct{}),
+				lost:     make(chan struct{}),
+			}
+			s.entries[key] = e
+			fallthrough
+		case e.owner == owner:
+			e.count++
+			s.prolong(key, e, ttl)
+			s.mu.Unlock()
+			return e, nil
+		}
+
+		s.mu.Unlock()
+
+		if !wait {
+			return nil, locker.ErrNotObtained
+		}
+
+		select {
+		case <-e.released:
+		case <-ctx.Done():
+			return nil, locker.ErrNotObtained
+		}
+	}
+}
+
+// Hold the lock by another owner until release is called
+func (s *service) Hold(key string) func() {
+	e, _ := s.acquire(context.Background(), key, helpers.NewUUID(), 0, true)
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.entries[key] == e {
+			s.remove(key, e, false)
+		}
+	}
+}
+
+// Lose the lock
+func (s *service) Lose(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		s.remove(key, e, true)
+	}
+}
+
+// obtain the lock for owner from context, waiting is limited by WaitTimeout
+func (s *service) obtain(key string, ttl time.Duration, wait bool) (*handle, error) {
+	if ttl < time.Millisecond {
+		return nil, locker.ErrInvalidTimeout
+	}
+
+	owner := locker.Owner(s.ctx)
+	if owner == "" {
+		owner = helpers.NewUUID()
+	}
+
+	ctx := s.ctx
+	if s.options.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.options.WaitTimeout)
+		defer cancel()
+	}
+
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	e, err := s.acquire(ctx, key, owner, ttl, wait && s.options.WaitTimeout > 0)
+	if err == locker.ErrNotObtained && s.ctx.Err() != nil {
+		// wait timeout isn't an error of caller's ctx:
+		return nil, s.ctx.Err()
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &handle{store: s.store, key: key, ttl: ttl, entry: e}, nil
+}
+
+// Obtain the lock for ttl
+func (s *service) Obtain(key string, ttl time.Duration) (locker.Handle, error) {
+	return s.obtain(key, ttl, true)
+}
+
+// TryObtain the lock for ttl without waiting
+func (s *service) TryObtain(key string, ttl time.Duration) (locker.Handle, error) {
+	return s.obtain(key, ttl, false)
+}
+
+// Run runs handler with the lock, the lock doesn't expire while handler runs
+func (s *service) Run(key string, timeout time.Duration, handler func(ctx context.Context)) error {
+	return s.run(key, timeout, true, handler)
+}
+
+// TryRun runs handler with the lock without waiting for it
+func (s *service) TryRun(key string, timeout time.Duration, handler func(ctx context.Context)) error {
+	return s.run(key, timeout, false, handler)
+}
+
+func (s *service) run(key string, timeout time.Duration, wait bool, handler func(ctx context.Context)) error {
+	h, err := s.obtain(key, timeout, wait)
+	if err != nil {
+		return err
+	}
+
+	var (
+		done        = make(chan struct{})
+		ctx, cancel = context.WithCancel(s.ctx)
+	)
+
+	go func() {
+		ticker := time.NewTicker(timeout / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-h.entry.lost:
+				cancel()
+				return
+			case <-ticker.C:
+				h.Refresh()
+			}
+		}
+	}()
+
+	stop := func() {
+		close(done)
+		cancel()
+	}
+
+	// lock of panicked handler is released before the panic goes on:
+	defer func() {
+		if rVal := recover(); rVal != nil {
+			stop()
+			h.Release()
+			panic(rVal)
+		}
+	}()
+
+	handler(ctx)
+	stop()
+
+	return h.Release()
+}
+
+// held checks that lock of handle is still held, should be called under lock
+func (h *handle) held() bool {
+	return h.store.entries[h.key] == h.entry
+}
+
+// Refresh prolongs the lock for its ttl
+func (h *handle) Refresh() error {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+
+	if !h.held() {
+		return locker.ErrLost
+	}
+
+	h.store.prolong(h.key, h.entry, h.ttl)
+	return nil
+}
+
+// Release the lock
+func (h *handle) Release() error {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+
+	if !h.held() {
+		return locker.ErrLost
+	}
+
+	if h.entry.count--; h.entry.count == 0 {
+		h.store.remove(h.key, h.entry, false)
+	}
+
+	return nil
+}
+
+// TTL returns remaining time of the lock
+func (h *handle) TTL() (time.Duration, error) {
+	h.store.mu.Lock()
+	defer h.store.mu.Unlock()
+
+	if !h.held() {
+		return 0, locker.ErrLost
+	}
+
+	return time.Until(h.entry.deadline), nil
+}
@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/locker"
+	"github.com/stretchr/testify/assert"
+)
+
+const testKey = "test:locker"
+
+func TestRun(t *testing.T) {
+	var (
+		ran bool
+		l   = New()
+	)
+
+	err := l.Run(testKey, 30*time.Millisecond, func(ctx context.Context) {
+		ran = true
+
+		// lock doesn't expire while handler runs:
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(t, locker.ErrNotObtained, l.TryRun(testKey, time.Second, func(context.Context) {}))
+		assert.NoError(t, ctx.Err())
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, ran)
+}
+
+func TestHold(t *testing.T) {
+	var (
+		ran     bool
+		l       = New(WaitTimeout(50 * time.Millisecond))
+		release = l.Hold(testKey)
+	)
+
+	err := l.Run(testKey, time.Second, func(context.Context) {
+		ran = true
+	})
+
+	assert.Equal(t, locker.ErrNotObtained, err)
+	assert.False(t, ran)
+
+	// waiting handler runs, when lock is released:
+	time.AfterFunc(10*time.Millisecond, release)
+
+	err = l.Run(testKey, time.Second, func(context.Context) {
+		ran = true
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, ran)
+}
+
+func TestLose(t *testing.T) {
+	l := New()
+
+	err := l.Run(testKey, time.Second, func(ctx context.Context) {
+		l.Lose(testKey)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Error("context is not cancelled")
+		}
+	})
+
+	assert.Equal(t, locker.ErrLost, err)
+}
+
+func TestPanic(t *testing.T) {
+	l := New()
+
+	assert.Panics(t, func() {
+		l.Run(testKey, 30*time.Millisecond, func(context.Context) {
+			panic("handler panic")
+		})
+	})
+
+	// lock of panicked handler is released:
+	assert.NoError(t, l.TryRun(testKey, time.Second, func(context.Context) {}))
+}
+
+func TestObtain(t *testing.T) {
+	l := New()
+
+	h, err := l.Obtain(testKey, 20*time.Millisecond)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if ttl, err := h.TTL(); !assert.NoError(t, err) || !assert.True(t, ttl > 0 && ttl <= 20*time.Millisecond) {
+		t.FailNow()
+	}
+
+	assert.NoError(t, h.Refresh())
+
+	// lock expires without refresh:
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, locker.ErrLost, h.Refresh())
+	assert.Equal(t, locker.ErrLost, h.Release())
+
+	_, err = h.TTL()
+	assert.Equal(t, locker.ErrLost, err)
+
+	_, err = l.Obtain(testKey, time.Microsecond)
+	assert.Equal(t, locker.ErrInvalidTimeout, err)
+}
+
+func TestReentrant(t *testing.T) {
+	var (
+		ran bool
+		l   = New(WaitTimeout(-1))
+		o   = l.WithContext(locker.WithOwner(context.Background(), "owner"))
+	)
+
+	err := o.Run(testKey, time.Second, func(ctx context.Context) {
+		assert.Equal(t, locker.ErrNotObtained, l.TryRun(testKey, time.Second, func(context.Context) {}))
+
+		assert.NoError(t, o.WithContext(ctx).Run(testKey, time.Second, func(context.Context) {
+			ran = true
+		}))
+
+		assert.Equal(t, locker.ErrNotObtained, l.Run(testKey, time.Second, func(context.Context) {}))
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, ran)
+	assert.NoError(t, l.TryRun(testKey, time.Second, func(context.Context) {}))
+
+	// nested lock with shorter ttl doesn't shorten the lock:
+	outer, err := o.Obtain(testKey, time.Minute)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	inner, err := o.Obtain(testKey, time.Second)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.NoError(t, inner.Refresh())
+
+	ttl, err := outer.TTL()
+	assert.NoError(t, err)
+	assert.True(t, ttl > time.Second, "ttl %s is shortened", ttl)
+
+	assert.NoError(t, inner.Release())
+	assert.NoError(t, outer.Release())
+}
@@ -0,0 +1,42 @@
+package memory
+
+import (
+	"time"
+
+	"github.com/cryptopay-dev/yaga/locker"
+)
+
+// DefaultWaitTimeout used when Options.WaitTimeout is not set,
+// it's equal to waiting of locker with default retries
+const DefaultWaitTimeout = locker.DefaultRetryCount * locker.DefaultRetryDelay
+
+// Options for creating memory-locker
+type Options struct {
+	// WaitTimeout limits waiting for the lock held by another owner,
+	// negative value disables waiting
+	WaitTimeout time.Duration
+}
+
+// Option closure
+type Option func(*Options)
+
+// newOptions converts slice of closures to Options-field
+func newOptions(opts ...Option) Options {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.WaitTimeout == 0 {
+		options.WaitTimeout = DefaultWaitTimeout
+	}
+
+	return options
+}
+
+// WaitTimeout closure to set field in Options
+func WaitTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.WaitTimeout = timeout
+	}
+}
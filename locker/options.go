@@ -1,14 +1,39 @@
 package locker
 
 import (
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers/backoff"
 	"github.com/cryptopay-dev/yaga/logger"
+	"github.com/cryptopay-dev/yaga/logger/nop"
+	"github.com/go-pg/pg"
 	"github.com/go-redis/redis"
 )
 
+const (
+	// DefaultRetryCount used when Options.RetryCount is not set
+	DefaultRetryCount = 10
+
+	// DefaultRetryDelay used by backoff when Options.Backoff is not set
+	DefaultRetryDelay = 100 * time.Millisecond
+)
+
 // Options for creating Locker instance
 type Options struct {
-	Redis  *redis.Client
+	Redis *redis.Client
+	// DB is used for Postgres advisory locks, when Redis is not set,
+	// every held lock takes connection from the pool
+	DB *pg.DB
+	// Logger of backend errors, nop-logger by default
 	Logger logger.Logger
+	// RetryCount of attempts to obtain the lock after the first one,
+	// negative value disables retries
+	RetryCount int
+	// Backoff returns delays between attempts,
+	// backoff.Fixed(DefaultRetryDelay, 0) by default
+	Backoff backoff.Backoff
+	// WaitTimeout limits overall waiting for the lock, zero means no limit
+	WaitTimeout time.Duration
 }
 
 // Option closure
@@ -20,6 +45,21 @@ func newOptions(opts ...Option) Options {
 	for _, o := range opts {
 		o(&options)
 	}
+
+	if options.RetryCount == 0 {
+		options.RetryCount = DefaultRetryCount
+	} else if options.RetryCount < 0 {
+		options.RetryCount = 0
+	}
+
+	if options.Backoff == nil {
+		options.Backoff = backoff.Fixed(DefaultRetryDelay, 0)
+	}
+
+	if options.Logger == nil {
+		options.Logger = nop.New()
+	}
+
 	return options
 }
 
@@ -30,9 +70,37 @@ func Redis(r *redis.Client) Option {
 	}
 }
 
+// DB closure to set field in Options
+func DB(db *pg.DB) Option {
+	return func(o *Options) {
+		o.DB = db
+	}
+}
+
 // Logger closure to set field in Options
 func Logger(l logger.Logger) Option {
 	return func(o *Options) {
 		o.Logger = l
 	}
 }
+
+// RetryCount closure to set field in Options
+func RetryCount(count int) Option {
+	return func(o *Options) {
+		o.RetryCount = count
+	}
+}
+
+// RetryBackoff closure to set Backoff field in Options
+func RetryBackoff(b backoff.Backoff) Option {
+	return func(o *Options) {
+		o.Backoff = b
+	}
+}
+
+// WaitTimeout closure to set field in Options
+func WaitTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.WaitTimeout = timeout
+	}
+}
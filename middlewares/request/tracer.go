@@ -1,17 +1,30 @@
 package request
 
 import (
+	"context"
+
 	"github.com/cryptopay-dev/yaga/helpers"
+	"github.com/cryptopay-dev/yaga/helpers/traceid"
 	"github.com/cryptopay-dev/yaga/logger"
 	"github.com/cryptopay-dev/yaga/web"
 )
 
 const (
-	RayTraceHeader = "X-Ray-Trace-ID"
+	RayTraceHeader = traceid.Header
 )
 
 type T = map[string]string
 
+// WithTraceID returns copy of ctx with trace ID
+func WithTraceID(ctx context.Context, id string) context.Context {
+	return traceid.With(ctx, id)
+}
+
+// TraceID returns trace ID from ctx, empty when it's not set
+func TraceID(ctx context.Context) string {
+	return traceid.From(ctx)
+}
+
 func rayTrace(ctx web.Context) (key, val string) {
 	key = RayTraceHeader
 	val = ctx.Request().Header.Get(key)
@@ -42,6 +55,7 @@ func RayTraceID(logger logger.Logger) web.MiddlewareFunc {
 			}
 
 			res.Header().Set(RayTraceHeader, id)
+			ctx.SetRequest(req.WithContext(WithTraceID(req.Context(), id)))
 
 			key, val := rayTrace(ctx)
 			ctx.Echo().Logger = logger.WithContext(map[string]interface{}{key: val})
@@ -43,6 +43,7 @@ func TestRayTraceID(t *testing.T) {
 		assert.Equal(t, c.Request().Header.Get(RayTraceHeader), fakeRayTraceID)
 		assert.Equal(t, tag, T{RayTraceHeader: fakeRayTraceID})
 		assert.Equal(t, field["X-Ray-Trace-ID"], fakeRayTraceID)
+		assert.Equal(t, TraceID(c.Request().Context()), fakeRayTraceID)
 		return c.NoContent(http.StatusOK)
 	})
 
@@ -0,0 +1,52 @@
+package queue
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"time"
+)
+
+const (
+	fileNameTpl = "%d_create_%s.%s.sql"
+
+	sqlCreateTableTpl = `CREATE TABLE IF NOT EXISTS %[1]s (
+	id bigserial,
+	name varchar(255) NOT NULL,
+	payload jsonb NOT NULL DEFAULT '{}',
+	status varchar(16) NOT NULL DEFAULT 'pending',
+	attempts integer NOT NULL DEFAULT 0,
+	max_attempts integer NOT NULL,
+	last_error text,
+	run_at timestamptz NOT NULL DEFAULT now(),
+	created_at timestamptz NOT NULL DEFAULT now(),
+	PRIMARY KEY(id)
+);
+
+CREATE INDEX IF NOT EXISTS %[1]s_pending_idx ON %[1]s (run_at) WHERE status = 'pending';
+`
+
+	sqlDropTableTpl = `DROP TABLE IF EXISTS %s;
+`
+)
+
+// CreateMigration files of jobs table in migrations folder,
+// which is applied by `migrate` with other migrations
+func CreateMigration(folder, table string) error {
+	var (
+		dt    = time.Now().Unix()
+		items = map[string]string{
+			"up":   fmt.Sprintf(sqlCreateTableTpl, table),
+			"down": fmt.Sprintf(sqlDropTableTpl, table),
+		}
+	)
+
+	for mType, sql := range items {
+		filename := path.Join(folder, fmt.Sprintf(fileNameTpl, dt, table, mType))
+		if err := ioutil.WriteFile(filename, []byte(sql), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
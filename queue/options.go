@@ -0,0 +1,139 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers/backoff"
+	"github.com/cryptopay-dev/yaga/logger"
+	"github.com/go-pg/pg"
+)
+
+const (
+	// DefaultTable of jobs used when Options.Table is not set
+	DefaultTable = "jobs"
+
+	// DefaultPollInterval used when Options.PollInterval is not set
+	DefaultPollInterval = time.Second
+
+	// DefaultConcurrency used when Options.Concurrency is not set
+	DefaultConcurrency = 1
+
+	// DefaultMaxAttempts used when Options.MaxAttempts is not set
+	DefaultMaxAttempts = 5
+
+	// DefaultTimeout used when Options.Timeout is not set
+	DefaultTimeout = 10 * time.Minute
+)
+
+// Options for creating Queue instance
+type Options struct {
+	DB     *pg.DB
+	Logger logger.Logger
+	// Table of jobs, created by migration from CreateMigration
+	Table string
+	// PollInterval between checks of the table, when there are no jobs to run
+	PollInterval time.Duration
+	// Concurrency is count of jobs running in parallel
+	Concurrency int
+	// MaxAttempts of the job, after them the job is moved to dead letter
+	MaxAttempts int
+	// Backoff returns delays between attempts,
+	// backoff.Exponential(time.Second, time.Hour, 0.1) by default
+	Backoff backoff.Backoff
+	// Timeout of the job run, claimed job isn't taken by other workers
+	// until a minute after it, so job of crashed process runs again later
+	Timeout time.Duration
+}
+
+// Option closure
+type Option func(*Options)
+
+// newOptions converts slice of closures to Options-field
+func newOptions(opts ...Option) Options {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.Table == "" {
+		options.Table = DefaultTable
+	}
+
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultPollInterval
+	}
+
+	if options.Concurrency <= 0 {
+		options.Concurrency = DefaultConcurrency
+	}
+
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if options.Backoff == nil {
+		options.Backoff = backoff.Exponential(time.Second, time.Hour, 0.1)
+	}
+
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultTimeout
+	}
+
+	return options
+}
+
+// DB closure to set field in Options
+func DB(db *pg.DB) Option {
+	return func(o *Options) {
+		o.DB = db
+	}
+}
+
+// Logger closure to set field in Options
+func Logger(l logger.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// Table closure to set field in Options
+func Table(name string) Option {
+	return func(o *Options) {
+		o.Table = name
+	}
+}
+
+// PollInterval closure to set field in Options
+func PollInterval(d time.Duration) Option {
+	return func(o *Options) {
+		o.PollInterval = d
+	}
+}
+
+// Concurrency closure to set field in Options
+func Concurrency(n int) Option {
+	return func(o *Options) {
+		o.Concurrency = n
+	}
+}
+
+// MaxAttempts closure to set field in Options
+func MaxAttempts(n int) Option {
+	return func(o *Options) {
+		o.MaxAttempts = n
+	}
+}
+
+// Backoff closure to set field in Options
+func Backoff(b backoff.Backoff) Option {
+	return func(o *Options) {
+		o.Backoff = b
+	}
+}
+
+// Timeout closure to set field in Options
+func Timeout(d time.Duration) Option {
+	return func(o *Options) {
+		o.Timeout = d
+	}
+}
@@ -0,0 +1,320 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/logger"
+	"github.com/cryptopay-dev/yaga/tracer"
+	"github.com/getsentry/raven-go"
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+const (
+	statusPending = "pending"
+	statusDead    = "dead"
+
+	sqlInsertJob = `INSERT INTO ? (name, payload, max_attempts, run_at)
+VALUES (?, ?, ?, now() + ? * interval '1 microsecond') RETURNING id`
+
+	// claimed job is counted and is hidden from other workers by lease,
+	// so the job of crashed process returns to the queue when lease is expired
+	sqlClaimJob = `WITH claimed AS (
+	SELECT id, run_at FROM ?
+	WHERE status = ? AND run_at <= now() AND name IN (?)
+	ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED
+)
+UPDATE ? AS j SET attempts = j.attempts + 1, run_at = now() + ? * interval '1 microsecond'
+FROM claimed WHERE j.id = claimed.id
+RETURNING j.id, j.name, j.payload, j.attempts, j.max_attempts, claimed.run_at, j.created_at`
+	// finish statements match the claimed attempt, so they don't touch
+	// the job, which is claimed again after lease of the attempt
+	sqlDeleteJob = `DELETE FROM ? WHERE id = ? AND attempts = ?`
+	sqlFailJob   = `UPDATE ? SET status = ?, last_error = ?,
+run_at = now() + ? * interval '1 microsecond' WHERE id = ? AND attempts = ?`
+	sqlReturnJob = `UPDATE ? SET attempts = attempts - 1, run_at = now() WHERE id = ? AND attempts = ?`
+
+	// leaseMargin is added to Options.Timeout for lease of the claimed job,
+	// the job is finished in it after handler is timed out
+	leaseMargin = time.Minute
+)
+
+var (
+	// ErrNoDB set to Options
+	ErrNoDB = errors.New("no db")
+
+	// ErrNoLogger set to Options
+	ErrNoLogger = errors.New("no logger")
+
+	// ErrAlreadyHandler is returned by Register calls
+	// when handler of the job name is already registered
+	ErrAlreadyHandler = errors.New("job handler must be unique")
+)
+
+type (
+	// Job stored in the table
+	Job struct {
+		ID      int64
+		Name    string
+		Payload json.RawMessage
+		// Attempts of the job including the running one
+		Attempts    int
+		MaxAttempts int
+		RunAt       time.Time
+		CreatedAt   time.Time
+	}
+
+	// Handler runs the job, returned error or panic fails the attempt,
+	// ctx is cancelled when Queue is stopped or Options.Timeout is exceeded
+	Handler func(ctx context.Context, job *Job) error
+
+	// Queue of durable jobs in Postgres. Jobs are claimed with
+	// SELECT ... FOR UPDATE SKIP LOCKED in a short transaction, which counts
+	// the attempt and hides the job for longer than Options.Timeout, so a job
+	// of crashed process is returned to the queue and poison jobs reach dead letter.
+	// Failed jobs are retried by backoff and are moved to dead letter
+	// (status 'dead') when attempts are exhausted.
+	Queue interface {
+		// Register handler of jobs with the name
+		Register(name string, handler Handler) error
+		// Enqueue the job to run as soon as possible, db is Options.DB
+		// or transaction of the caller, which commits the job with its changes
+		Enqueue(db orm.DB, name string, payload interface{}) (int64, error)
+		// EnqueueIn enqueues the job to run after delay
+		EnqueueIn(db orm.DB, name string, payload interface{}, delay time.Duration) (int64, error)
+		// Run jobs until ctx is done, waits for running jobs
+		Run(ctx context.Context) error
+	}
+
+	queue struct {
+		db      *pg.DB
+		logger  logger.Logger
+		options Options
+
+		mu       sync.RWMutex
+		handlers map[string]Handler
+	}
+)
+
+// Bind payload of the job to v
+func (j *Job) Bind(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// New creates instance of Queue
+func New(opts ...Option) (Queue, error) {
+	options := newOptions(opts...)
+
+	if options.DB == nil {
+		return nil, ErrNoDB
+	}
+
+	if options.Logger == nil {
+		return nil, ErrNoLogger
+	}
+
+	return &queue{
+		db:       options.DB,
+		logger:   options.Logger,
+		options:  options,
+		handlers: make(map[string]Handler),
+	}, nil
+}
+
+// Register handler of jobs with the name
+func (q *queue) Register(name string, handler Handler) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, found := q.handlers[name]; found {
+		return ErrAlreadyHandler
+	}
+
+	q.handlers[name] = handler
+	return nil
+}
+
+// Enqueue the job to run as soon as possible
+func (q *queue) Enqueue(db orm.DB, name string, payload interface{}) (int64, error) {
+	return q.EnqueueIn(db, name, payload, 0)
+}
+
+// EnqueueIn enqueues the job to run after delay
+func (q *queue) EnqueueIn(db orm.DB, name string, payload interface{}, delay time.Duration) (int64, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	_, err = db.QueryOne(pg.Scan(&id), sqlInsertJob,
+		pg.Q(q.options.Table), name, json.RawMessage(data), q.options.MaxAttempts, microseconds(delay))
+
+	return id, err
+}
+
+// Run jobs until ctx is done, waits for running jobs
+func (q *queue) Run(ctx context.Context) error {
+	wg := new(sync.WaitGroup)
+
+	for i := 0; i < q.options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.poll(ctx)
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// poll the table for jobs until ctx is done
+func (q *queue) poll(ctx context.Context) {
+	for {
+		found, err := q.work(ctx)
+		if err != nil {
+			q.logger.Errorf("Queue error: %v", err)
+		}
+
+		// next job is taken at once:
+		if found && err == nil && ctx.Err() == nil {
+			continue
+		}
+
+		delay := time.NewTimer(q.options.PollInterval)
+
+		select {
+		case <-ctx.Done():
+			delay.Stop()
+			return
+		case <-delay.C:
+		}
+	}
+}
+
+// work claims a job and runs it, the job isn't locked while it runs
+func (q *queue) work(ctx context.Context) (bool, error) {
+	job, err := q.claim()
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	// job of crashed process is counted, but isn't finished:
+	if job.Attempts > job.MaxAttempts {
+		return true, q.fail(job, errors.New("attempts are exhausted"))
+	}
+
+	run, cancel := context.WithTimeout(ctx, q.options.Timeout)
+	errJob := q.handle(run, job)
+	cancel()
+
+	switch {
+	case errJob == nil:
+		return true, q.finish(job, sqlDeleteJob, pg.Q(q.options.Table), job.ID, job.Attempts)
+	case ctx.Err() != nil:
+		// the job is interrupted by stop of the queue, attempt isn't counted:
+		return true, q.finish(job, sqlReturnJob, pg.Q(q.options.Table), job.ID, job.Attempts)
+	default:
+		return true, q.fail(job, errJob)
+	}
+}
+
+// claim the next job, nil is returned when there are no jobs to run
+func (q *queue) claim() (*Job, error) {
+	names := q.names()
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	job := new(Job)
+	_, err := q.db.QueryOne(job, sqlClaimJob, pg.Q(q.options.Table), statusPending, pg.In(names),
+		pg.Q(q.options.Table), microseconds(q.options.Timeout+leaseMargin))
+	if err == pg.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// fail the attempt of the job, it's retried by backoff
+// or is moved to dead letter when attempts are exhausted
+func (q *queue) fail(job *Job, errJob error) error {
+	var (
+		status = statusPending
+		delay  time.Duration
+	)
+
+	if job.Attempts >= job.MaxAttempts {
+		status = statusDead
+		q.logger.Errorf("Queue job moved to dead letter: id=%d, name=%s, attempts=%d, err=%v",
+			job.ID, job.Name, job.Attempts, errJob)
+	} else {
+		delay = q.options.Backoff(job.Attempts)
+		q.logger.Warnf("Queue job failed: id=%d, name=%s, attempt=%d, retry in %s, err=%v",
+			job.ID, job.Name, job.Attempts, delay, errJob)
+	}
+
+	return q.finish(job, sqlFailJob, pg.Q(q.options.Table),
+		status, errJob.Error(), microseconds(delay), job.ID, job.Attempts)
+}
+
+// finish the attempt of the job by query, the job isn't changed
+// when it's claimed again, because the attempt outlived its lease
+func (q *queue) finish(job *Job, query string, params ...interface{}) error {
+	res, err := q.db.Exec(query, params...)
+	if err != nil {
+		return err
+	}
+
+	if res.RowsAffected() == 0 {
+		q.logger.Warnf("Queue job attempt outlived its lease: id=%d, name=%s, attempt=%d",
+			job.ID, job.Name, job.Attempts)
+	}
+
+	return nil
+}
+
+// handle the job with its handler, panic is converted
+// to error, which is sent to Sentry
+func (q *queue) handle(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if rVal := recover(); rVal != nil {
+			err = fmt.Errorf("job panic: %v", rVal)
+
+			packet := tracer.StackPacket(err)
+			raven.Capture(packet, map[string]string{"job": job.Name})
+		}
+	}()
+
+	q.mu.RLock()
+	handler := q.handlers[job.Name]
+	q.mu.RUnlock()
+
+	return handler(ctx, job)
+}
+
+// names of jobs with registered handlers
+func (q *queue) names() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	names := make([]string, 0, len(q.handlers))
+	for name := range q.handlers {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// microseconds of delay for SQL intervals
+func microseconds(d time.Duration) int64 {
+	return int64(d / time.Microsecond)
+}
@@ -0,0 +1,181 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers/backoff"
+	"github.com/cryptopay-dev/yaga/helpers/testdb"
+	"github.com/cryptopay-dev/yaga/logger/nop"
+	"github.com/go-pg/pg"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(Logger(nop.New()))
+	assert.Equal(t, ErrNoDB, err)
+
+	_, err = New(DB(testdb.GetTestDB().DB))
+	assert.Equal(t, ErrNoLogger, err)
+
+	q, err := New(DB(testdb.GetTestDB().DB), Logger(nop.New()))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	handler := func(context.Context, *Job) error { return nil }
+	assert.NoError(t, q.Register("job", handler))
+	assert.Equal(t, ErrAlreadyHandler, q.Register("job", handler))
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "queue")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	if !assert.NoError(t, CreateMigration(dir, "jobs")) {
+		t.FailNow()
+	}
+
+	for _, mType := range []string{"up", "down"} {
+		files, err := filepath.Glob(filepath.Join(dir, "*_create_jobs."+mType+".sql"))
+		if !assert.NoError(t, err) || !assert.Len(t, files, 1) {
+			t.FailNow()
+		}
+
+		data, err := ioutil.ReadFile(files[0])
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "jobs")
+	}
+}
+
+func TestQueue(t *testing.T) {
+	var (
+		db    = testdb.GetTestDB().DB
+		table = fmt.Sprintf("jobs_test_%d", time.Now().UnixNano())
+		done  = atomic.NewInt32(0)
+		fails = atomic.NewInt32(0)
+
+		stopped = atomic.NewInt32(0)
+	)
+
+	_, err := db.Exec(fmt.Sprintf(sqlCreateTableTpl, table))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer db.Exec(fmt.Sprintf(sqlDropTableTpl, table))
+
+	q, err := New(
+		DB(db),
+		Logger(nop.New()),
+		Table(table),
+		PollInterval(10*time.Millisecond),
+		MaxAttempts(2),
+		Concurrency(2),
+		Backoff(backoff.Fixed(time.Millisecond, 0)),
+	)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.NoError(t, q.Register("ok", func(_ context.Context, job *Job) error {
+		var payload map[string]string
+		if err := job.Bind(&payload); err != nil {
+			return err
+		}
+		assert.Equal(t, "value", payload["key"])
+		assert.Equal(t, 1, job.Attempts)
+		done.Inc()
+		return nil
+	}))
+	assert.NoError(t, q.Register("stopped", func(ctx context.Context, _ *Job) error {
+		stopped.Inc()
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	assert.NoError(t, q.Register("fail", func(context.Context, *Job) error {
+		if fails.Inc() == 1 {
+			panic("first attempt")
+		}
+		return errors.New("always fails")
+	}))
+
+	// job is enqueued by transaction of the caller:
+	err = db.RunInTransaction(func(tx *pg.Tx) error {
+		_, errEnqueue := q.Enqueue(tx, "ok", map[string]string{"key": "value"})
+		return errEnqueue
+	})
+	assert.NoError(t, err)
+	failID, err := q.Enqueue(db, "fail", nil)
+	assert.NoError(t, err)
+	delayedID, err := q.EnqueueIn(db, "ok", map[string]string{"key": "value"}, time.Hour)
+	assert.NoError(t, err)
+	stoppedID, err := q.Enqueue(db, "stopped", nil)
+	assert.NoError(t, err)
+
+	// job, which crashed the process on every attempt:
+	var crashedID int64
+	_, err = db.QueryOne(pg.Scan(&crashedID), `INSERT INTO ? (name, attempts, max_attempts)
+VALUES ('stopped', 2, 2) RETURNING id`, pg.Q(table))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	finished := make(chan error)
+	go func() { finished <- q.Run(ctx) }()
+
+	limit := time.Now().Add(5 * time.Second)
+	for time.Now().Before(limit) && (done.Load() < 1 || fails.Load() < 2 || stopped.Load() < 1) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	assert.NoError(t, <-finished)
+
+	assert.Equal(t, int32(1), done.Load(), "Delayed job runs before its time")
+	assert.Equal(t, int32(2), fails.Load())
+	assert.Equal(t, int32(1), stopped.Load(), "Crashed job runs after its attempts")
+
+	var status struct {
+		Status    string
+		Attempts  int
+		LastError string
+	}
+
+	_, err = db.QueryOne(&status, "SELECT status, attempts, last_error FROM ? WHERE id = ?", pg.Q(table), failID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, statusDead, status.Status)
+		assert.Equal(t, 2, status.Attempts)
+		assert.True(t, strings.Contains(status.LastError, "always fails"))
+	}
+
+	// attempt, which outlived its lease, doesn't finish the job:
+	stale := &Job{ID: delayedID, Attempts: 1}
+	assert.NoError(t, q.(*queue).finish(stale, sqlDeleteJob, pg.Q(table), stale.ID, stale.Attempts))
+
+	_, err = db.QueryOne(&status, "SELECT status, attempts, last_error FROM ? WHERE id = ?", pg.Q(table), delayedID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, statusPending, status.Status)
+	}
+
+	_, err = db.QueryOne(&status, "SELECT status, attempts, last_error FROM ? WHERE id = ?", pg.Q(table), stoppedID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, statusPending, status.Status)
+		assert.Equal(t, 0, status.Attempts, "Stop of the queue fails the attempt")
+	}
+
+	_, err = db.QueryOne(&status, "SELECT status, attempts, last_error FROM ? WHERE id = ?", pg.Q(table), crashedID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, statusDead, status.Status)
+		assert.Equal(t, 3, status.Attempts)
+	}
+}
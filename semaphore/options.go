@@ -0,0 +1,88 @@
+package semaphore
+
+import (
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers/backoff"
+	"github.com/cryptopay-dev/yaga/locker"
+	"github.com/cryptopay-dev/yaga/logger"
+	"github.com/cryptopay-dev/yaga/logger/nop"
+	"github.com/go-redis/redis"
+)
+
+// DefaultPermits used when Options.Permits is not set
+const DefaultPermits = 1
+
+// Options for creating Semaphore instance
+type Options struct {
+	Redis *redis.Client
+	// Logger of Redis errors, nop-logger by default
+	Logger logger.Logger
+	// Permits is a count of concurrent holders of key
+	Permits int
+	// Backoff returns delays between attempts to acquire permit,
+	// backoff.Fixed(locker.DefaultRetryDelay, 0) by default
+	Backoff backoff.Backoff
+	// WaitTimeout limits waiting for permit, zero means waiting until context is done
+	WaitTimeout time.Duration
+}
+
+// Option closure
+type Option func(*Options)
+
+// newOptions converts slice of closures to Options-field
+func newOptions(opts ...Option) Options {
+	var options Options
+	for _, o := range opts {
+		o(&options)
+	}
+
+	if options.Permits <= 0 {
+		options.Permits = DefaultPermits
+	}
+
+	if options.Backoff == nil {
+		options.Backoff = backoff.Fixed(locker.DefaultRetryDelay, 0)
+	}
+
+	if options.Logger == nil {
+		options.Logger = nop.New()
+	}
+
+	return options
+}
+
+// Redis closure to set field in Options
+func Redis(r *redis.Client) Option {
+	return func(o *Options) {
+		o.Redis = r
+	}
+}
+
+// Logger closure to set field in Options
+func Logger(l logger.Logger) Option {
+	return func(o *Options) {
+		o.Logger = l
+	}
+}
+
+// Permits closure to set field in Options
+func Permits(n int) Option {
+	return func(o *Options) {
+		o.Permits = n
+	}
+}
+
+// RetryBackoff closure to set Backoff field in Options
+func RetryBackoff(b backoff.Backoff) Option {
+	return func(o *Options) {
+		o.Backoff = b
+	}
+}
+
+// WaitTimeout closure to set field in Options
+func WaitTimeout(timeout time.Duration) Option {
+	return func(o *Options) {
+		o.WaitTimeout = timeout
+	}
+}
@@ -0,0 +1,216 @@
+package semaphore
+
+import (
+	"context"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers"
+	"github.com/cryptopay-dev/yaga/locker"
+	"github.com/cryptopay-dev/yaga/logger"
+	"github.com/go-redis/redis"
+)
+
+// Scripts read time of Redis server, so expiry of permits
+// doesn't depend on clocks of processes
+var (
+	// acquirePermit adds holder ARGV[1] to KEYS[1] for ARGV[2] ms,
+	// when count of unexpired holders is less than ARGV[3]
+	acquirePermit = redis.NewScript(`
+redis.replicate_commands()
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local ttl = tonumber(ARGV[2])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+
+if redis.call('ZCARD', KEYS[1]) >= tonumber(ARGV[3]) then
+	return 0
+end
+
+redis.call('ZADD', KEYS[1], now + ttl, ARGV[1])
+if redis.call('PTTL', KEYS[1]) < ttl then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+	// refreshPermit prolongs holder ARGV[1] of KEYS[1] for ARGV[2] ms,
+	// when it is not expired
+	refreshPermit = redis.NewScript(`
+redis.replicate_commands()
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local ttl = tonumber(ARGV[2])
+local expiry = redis.call('ZSCORE', KEYS[1], ARGV[1])
+
+if not expiry or tonumber(expiry) <= now then
+	return 0
+end
+
+redis.call('ZADD', KEYS[1], now + ttl, ARGV[1])
+if redis.call('PTTL', KEYS[1]) < ttl then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+)
+
+// Semaphore limits count of concurrent holders of key across processes.
+// Errors are the same as of locker.Locker.
+type Semaphore interface {
+	// Run runs handler holding a permit of key, waits while all permits are taken.
+	// Timeout is a lease of the permit, which is renewed while handler runs,
+	// handler context is cancelled when the permit is lost.
+	Run(key string, timeout time.Duration, handler func(ctx context.Context)) error
+	// TryRun runs handler holding a permit of key, returns
+	// locker.ErrNotObtained at once when all permits are taken
+	TryRun(key string, timeout time.Duration, handler func(ctx context.Context)) error
+	// WithContext returns Semaphore, which stops waiting for permit when ctx is done
+	WithContext(ctx context.Context) Semaphore
+}
+
+type semaphore struct {
+	redis   *redis.Client
+	logger  logger.Logger
+	options Options
+	ctx     context.Context
+}
+
+// permit held by semaphore
+type permit struct {
+	redis  *redis.Client
+	key    string
+	holder string
+	ttl    time.Duration
+}
+
+// New creates instance of Semaphore
+func New(opts ...Option) Semaphore {
+	var options = newOptions(opts...)
+	return &semaphore{
+		redis:   options.Redis,
+		logger:  options.Logger,
+		options: options,
+		ctx:     context.Background(),
+	}
+}
+
+// WithContext returns copy of semaphore bound to ctx
+func (s *semaphore) WithContext(ctx context.Context) Semaphore {
+	if ctx == nil {
+		panic("nil context")
+	}
+
+	c := *s
+	c.redis = s.redis.WithContext(ctx)
+	c.ctx = ctx
+	return &c
+}
+
+// milliseconds of permit ttl
+func (p *permit) milliseconds() int64 {
+	return int64(p.ttl / time.Millisecond)
+}
+
+// acquire tries to take permit once
+func (p *permit) acquire(permits int) (bool, error) {
+	ok, err := acquirePermit.Run(p.redis, []string{p.key}, p.holder, p.milliseconds(), permits).Int64()
+	return ok == 1, err
+}
+
+// refresh prolongs permit for ttl, reports false when permit is lost
+func (p *permit) refresh() (bool, error) {
+	ok, err := refreshPermit.Run(p.redis, []string{p.key}, p.holder, p.milliseconds()).Int64()
+	return ok == 1, err
+}
+
+// release permit, reports false when permit was already lost
+func (p *permit) release() (bool, error) {
+	n, err := p.redis.ZRem(p.key, p.holder).Result()
+	return n == 1, err
+}
+
+// Run runs handler holding a permit of key
+func (s *semaphore) Run(key string, timeout time.Duration, handler func(ctx context.Context)) error {
+	return s.run(key, timeout, true, handler)
+}
+
+// TryRun runs handler holding a permit of key without waiting
+func (s *semaphore) TryRun(key string, timeout time.Duration, handler func(ctx context.Context)) error {
+	return s.run(key, timeout, false, handler)
+}
+
+// acquire permit, retrying with backoff until wait timeout is exceeded or ctx is done
+func (s *semaphore) acquire(p *permit, wait bool) error {
+	ctx := s.ctx
+	if s.options.WaitTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.options.WaitTimeout)
+		defer cancel()
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+
+		if ok, err := p.acquire(s.options.Permits); err != nil {
+			return s.redisError(err)
+		} else if ok {
+			return nil
+		}
+
+		if !wait {
+			return locker.ErrNotObtained
+		}
+
+		delay := time.NewTimer(s.options.Backoff(attempt))
+
+		select {
+		case <-ctx.Done():
+			delay.Stop()
+			// wait timeout isn't an error of caller's ctx:
+			if err := s.ctx.Err(); err != nil {
+				return err
+			}
+			return locker.ErrNotObtained
+		case <-delay.C:
+		}
+	}
+}
+
+// run acquires permit and runs handler, while keeper renews permit
+func (s *semaphore) run(key string, timeout time.Duration, wait bool, handler func(ctx context.Context)) error {
+	if timeout < time.Millisecond {
+		return locker.ErrInvalidTimeout
+	}
+
+	p := &permit{
+		redis:  s.redis,
+		key:    key,
+		holder: helpers.NewUUID(),
+		ttl:    timeout,
+	}
+
+	if err := s.acquire(p, wait); err != nil {
+		return err
+	}
+
+	return s.keeper().Run(s.ctx, timeout, p.refresh, p.release, handler)
+}
+
+// keeper of permits of the semaphore
+func (s *semaphore) keeper() locker.Keeper {
+	return locker.Keeper{
+		Logger:  s.logger,
+		Name:    "Semaphore",
+		Failure: locker.ErrRedis,
+	}
+}
+
+// redisError logs the cause and returns locker.ErrRedis
+func (s *semaphore) redisError(err error) error {
+	return s.keeper().Error(err)
+}
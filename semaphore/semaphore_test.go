@@ -0,0 +1,104 @@
+package semaphore
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers/backoff"
+	"github.com/cryptopay-dev/yaga/locker"
+	"github.com/cryptopay-dev/yaga/logger/nop"
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+const testKey = "test:semaphore"
+
+// defaultSemaphore connects to TEST_REDIS_ADDR, test is skipped when Redis is unavailable
+func defaultSemaphore(t *testing.T, opts ...Option) Semaphore {
+	client := redis.NewClient(&redis.Options{
+		Addr:        os.Getenv("TEST_REDIS_ADDR"),
+		DialTimeout: time.Second,
+	})
+
+	if err := client.Ping().Err(); err != nil {
+		client.Close()
+		t.Skipf("redis is unavailable: %v", err)
+	}
+
+	return New(append([]Option{
+		Logger(nop.New()),
+		Redis(client),
+	}, opts...)...)
+}
+
+func TestSemaphore(t *testing.T) {
+	var (
+		wg      sync.WaitGroup
+		current = atomic.NewInt32(0)
+		maximum = atomic.NewInt32(0)
+		s       = defaultSemaphore(t, Permits(2), RetryBackoff(backoff.Fixed(5*time.Millisecond, 0)))
+	)
+
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := s.Run(testKey, time.Second, func(context.Context) {
+				if n := current.Inc(); n > maximum.Load() {
+					maximum.Store(n)
+				}
+				time.Sleep(20 * time.Millisecond)
+				current.Dec()
+			})
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(2), maximum.Load())
+}
+
+func TestTryRun(t *testing.T) {
+	s := defaultSemaphore(t, Permits(1))
+
+	err := s.Run(testKey, 30*time.Millisecond, func(ctx context.Context) {
+		// permit is renewed while handler runs:
+		time.Sleep(100 * time.Millisecond)
+
+		assert.Equal(t, locker.ErrNotObtained, s.TryRun(testKey, time.Second, func(context.Context) {}))
+		assert.NoError(t, ctx.Err())
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.TryRun(testKey, time.Second, func(context.Context) {}))
+}
+
+func TestWaitTimeout(t *testing.T) {
+	var (
+		s       = defaultSemaphore(t, WaitTimeout(50*time.Millisecond))
+		started = make(chan struct{})
+		release = make(chan struct{})
+		done    = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+		s.Run(testKey, time.Second, func(context.Context) {
+			close(started)
+			<-release
+		})
+	}()
+
+	<-started
+
+	assert.Equal(t, locker.ErrNotObtained, s.Run(testKey, time.Second, func(context.Context) {}))
+
+	close(release)
+	<-done
+}
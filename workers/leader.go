@@ -0,0 +1,133 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/locker"
+	"github.com/cryptopay-dev/yaga/logger"
+	"go.uber.org/atomic"
+)
+
+const (
+	// DefaultLeaderKey used when ElectionOptions.Key is not set
+	DefaultLeaderKey = "workers:leader"
+
+	// DefaultLeaderTTL used when ElectionOptions.TTL is not set
+	DefaultLeaderTTL = 10 * time.Second
+)
+
+type (
+	// ElectionOptions structure for leader election between replicas.
+	ElectionOptions struct {
+		Locker locker.Locker
+		Logger logger.Logger
+		// Key of the leader lock, should be the same for all replicas
+		Key string
+		// TTL of the leader lease, which is renewed while instance is leader
+		TTL time.Duration
+	}
+
+	// election of leader, only leader dispatches jobs
+	election struct {
+		options ElectionOptions
+		clock   Clock
+		leader  *atomic.Bool
+
+		mu     sync.Mutex
+		stopCh chan struct{}
+		doneCh chan struct{}
+	}
+)
+
+// ErrWrongElectionOptions is returned by ElectLeader calls
+// when parameter ElectionOptions.Locker or ElectionOptions.Logger is NIL.
+var ErrWrongElectionOptions = errors.New("wrong election options")
+
+func newElection(opts ElectionOptions, clock Clock) (*election, error) {
+	if opts.Locker == nil || opts.Logger == nil {
+		return nil, ErrWrongElectionOptions
+	}
+
+	if opts.Key == "" {
+		opts.Key = DefaultLeaderKey
+	}
+
+	if opts.TTL <= 0 {
+		opts.TTL = DefaultLeaderTTL
+	}
+
+	return &election{
+		options: opts,
+		clock:   clock,
+		leader:  atomic.NewBool(false),
+	}, nil
+}
+
+// isLeader reports whether instance is elected
+func (e *election) isLeader() bool {
+	return e.leader.Load()
+}
+
+// start campaign for leadership
+func (e *election) start() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stopCh != nil {
+		return
+	}
+
+	e.stopCh = make(chan struct{})
+	e.doneCh = make(chan struct{})
+
+	go e.campaign(e.stopCh, e.doneCh)
+}
+
+// stop campaign and release leadership, so another replica can be elected
+func (e *election) stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.stopCh == nil {
+		return
+	}
+
+	close(e.stopCh)
+	<-e.doneCh
+
+	e.stopCh, e.doneCh = nil, nil
+}
+
+// campaign tries to obtain the leader lock every third of ttl until stopped
+func (e *election) campaign(stop, done chan struct{}) {
+	defer close(done)
+
+	for {
+		err := e.options.Locker.TryRun(e.options.Key, e.options.TTL, func(ctx context.Context) {
+			e.leader.Store(true)
+			e.options.Logger.Infof("Workers leadership acquired: key=%s", e.options.Key)
+
+			select {
+			case <-ctx.Done():
+				e.leader.Store(false)
+				e.options.Logger.Warnf("Workers leadership lost: key=%s", e.options.Key)
+			case <-stop:
+				e.leader.Store(false)
+				e.options.Logger.Infof("Workers leadership released: key=%s", e.options.Key)
+			}
+		})
+
+		if err != nil && err != locker.ErrNotObtained && err != locker.ErrLost {
+			e.options.Logger.Warnf("Workers leader election error: %v", err)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-e.clock.After(e.options.TTL / 3):
+		}
+	}
+}
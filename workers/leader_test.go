@@ -0,0 +1,72 @@
+package workers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/locker/memory"
+	"github.com/cryptopay-dev/yaga/logger/nop"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+func TestLeaderElection(t *testing.T) {
+	if _, err := newElection(ElectionOptions{}, systemClock{}); !assert.Equal(t, ErrWrongElectionOptions, err) {
+		t.FailNow()
+	}
+
+	var (
+		l      = memory.New()
+		first  = atomic.NewInt32(0)
+		second = atomic.NewInt32(0)
+		s1     = newSchedulerForTest()
+		s2     = newSchedulerForTest()
+		name   = getUniqueWorkerName()
+	)
+
+	for _, s := range []*schedulerForTest{s1, s2} {
+		err := s.ElectLeader(ElectionOptions{Locker: l, Logger: nop.New(), TTL: 30 * time.Millisecond})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	if err := s1.create(name, func() { first.Inc() }); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if err := s2.create(name, func() { second.Inc() }); !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	s1.Start()
+	defer s1.Stop()
+
+	if !s1.tickUntil(func() bool { return first.Load() > 0 }) {
+		assert.FailNow(t, "First replica is not elected")
+	}
+
+	s2.Start()
+	defer s2.Stop()
+
+	for i := 0; i < 5; i++ {
+		if !assert.True(t, s2.tick(), "Scheduler doesn't wait for clock") {
+			t.FailNow()
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if !assert.Equal(t, int32(0), second.Load(), "Both replicas dispatch jobs") {
+		t.FailNow()
+	}
+
+	// leadership is handed over on stop:
+	s1.stop()
+	first.Store(0)
+
+	if !s2.tickUntil(func() bool { return second.Load() > 0 }) {
+		assert.FailNow(t, "Second replica is not elected")
+	}
+
+	assert.True(t, s1.stopped(first))
+	assert.Equal(t, int32(0), first.Load())
+}
@@ -1,7 +1,9 @@
 package workers
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"go.uber.org/atomic"
 )
@@ -14,23 +16,42 @@ const (
 )
 
 type pool struct {
+	// clock of runs statistics and retry delays
+	clock   Clock
 	running *atomic.Bool
+	// election is set in leader election mode
+	election *election
 
 	mu      sync.Mutex
 	workers map[string]*worker
 
+	// limit of parallel runs and active runs
+	slotsMu sync.Mutex
+	limit   int
+	active  int
+
+	dropped *atomic.Int64
+
 	cmdCh  chan commandAction
-	jobCh  chan func()
+	jobCh  chan tickJob
 	waitCh chan chan struct{}
 }
 
-func newPool() *pool {
+// tickJob is a run of the worker for the tick
+type tickJob struct {
+	worker *worker
+	tick   time.Time
+}
+
+func newPool(clock Clock) *pool {
 	p := &pool{
+		clock:   clock,
 		workers: make(map[string]*worker),
 		running: atomic.NewBool(false),
+		dropped: atomic.NewInt64(0),
 
 		cmdCh:  make(chan commandAction, 1),
-		jobCh:  make(chan func(), 8),
+		jobCh:  make(chan tickJob, 8),
 		waitCh: make(chan chan struct{}),
 	}
 	go p.dispatcher()
@@ -51,32 +72,142 @@ func (p *pool) createWorker(opts Options) (*worker, error) {
 	p.workers[opts.Name] = w
 	p.mu.Unlock()
 
-	w.job = func() {
-		if !p.running.Load() {
+	w.job = func(tick time.Time) {
+		if w.isPaused() {
 			return
 		}
 
-		select {
-		case p.jobCh <- w.options.Handler:
-		default:
+		if err := p.dispatch(w, tick); err != nil && err != ErrNotRunning {
+			p.drop(w, err)
 		}
 	}
 
 	return w, nil
 }
 
+// removeWorker from pool, its runs aren't dispatched anymore
+func (p *pool) removeWorker(w *worker) {
+	p.mu.Lock()
+	if p.workers[w.options.Name] == w {
+		delete(p.workers, w.options.Name)
+	}
+	p.mu.Unlock()
+
+	w.remove()
+}
+
+// dispatch run of the worker for the tick, returns a reason when the run is dropped
+func (p *pool) dispatch(w *worker, tick time.Time) error {
+	if !p.running.Load() || !p.elected() || w.isRemoved() {
+		return ErrNotRunning
+	}
+
+	if ok, err := w.acquire(tick); err != nil {
+		return err
+	} else if !ok {
+		return nil
+	}
+
+	if !p.obtainSlot() {
+		p.reject(w)
+		return ErrPoolBusy
+	}
+
+	select {
+	case p.jobCh <- tickJob{worker: w, tick: tick}:
+		return nil
+	default:
+		p.releaseSlot()
+		p.reject(w)
+		return ErrPoolBusy
+	}
+}
+
+// reject the acquired run of worker, queued run is dropped too
+func (p *pool) reject(w *worker) {
+	if _, queued := w.finish(false); queued {
+		p.drop(w, ErrPoolBusy)
+	}
+}
+
+// drop logs and counts dropped tick of worker
+func (p *pool) drop(w *worker, reason error) {
+	p.dropped.Inc()
+	w.dropped.Inc()
+
+	if w.options.Logger != nil {
+		w.options.Logger.Warnf("Worker tick dropped: name=%s, reason=%v", w.options.Name, reason)
+	}
+}
+
+// setLimit of parallel runs, zero or negative n removes the limit
+func (p *pool) setLimit(n int) {
+	p.slotsMu.Lock()
+	p.limit = n
+	p.slotsMu.Unlock()
+}
+
+// obtainSlot of parallel runs, returns false when all slots are busy
+func (p *pool) obtainSlot() bool {
+	p.slotsMu.Lock()
+	defer p.slotsMu.Unlock()
+
+	if p.limit > 0 && p.active >= p.limit {
+		return false
+	}
+
+	p.active++
+	return true
+}
+
+// releaseSlot of parallel runs
+func (p *pool) releaseSlot() {
+	p.slotsMu.Lock()
+	p.active--
+	p.slotsMu.Unlock()
+}
+
+// elect sets election, only elected pool dispatches jobs
+func (p *pool) elect(e *election) {
+	p.mu.Lock()
+	p.election = e
+	p.mu.Unlock()
+}
+
+// elected reports whether pool can dispatch jobs
+func (p *pool) elected() bool {
+	e := p.currentElection()
+	return e == nil || e.isLeader()
+}
+
 func (p *pool) start() {
 	if !p.running.Swap(true) {
 		p.cmdCh <- start
+
+		if e := p.currentElection(); e != nil {
+			e.start()
+		}
 	}
 }
 
 func (p *pool) stop() {
 	if p.running.Swap(false) {
 		p.cmdCh <- stop
+
+		// leadership is released, when running jobs are finished:
+		if e := p.currentElection(); e != nil {
+			p.wait()
+			e.stop()
+		}
 	}
 }
 
+func (p *pool) currentElection() *election {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.election
+}
+
 func (p *pool) wait() {
 	<-<-p.waitCh
 }
@@ -86,27 +217,34 @@ func (p *pool) dispatcher() {
 		running bool
 		waiter  chan struct{}
 		wg      = new(sync.WaitGroup)
+		ctx     context.Context
+		cancel  context.CancelFunc
 	)
 
 	for {
 		select {
 		case job := <-p.jobCh:
 			if !running {
+				job.worker.finish(false)
+				p.releaseSlot()
 				continue
 			}
 			wg.Add(1)
-			go func() {
+			go func(ctx context.Context) {
 				defer wg.Done()
-				job()
-			}()
+				job.worker.run(ctx, job.tick)
+			}(ctx)
 		case p.waitCh <- waiter:
 		case cmd := <-p.cmdCh:
 			switch cmd {
 			case stop:
+				// jobs are asked to finish early:
+				cancel()
 				wg.Wait()
 				close(waiter)
 				running = false
 			case start:
+				ctx, cancel = context.WithCancel(context.Background())
 				waiter = make(chan struct{})
 				running = true
 			default:
@@ -0,0 +1,322 @@
+package workers
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/graceful"
+)
+
+type (
+	// Clock provides current time and timers for Scheduler: schedules,
+	// statistics of runs, retry delays and leader election use it,
+	// it can be replaced in tests to control time.
+	Clock interface {
+		Now() time.Time
+		After(d time.Duration) <-chan time.Time
+	}
+
+	// SchedulerOptions structure for creation new Scheduler.
+	SchedulerOptions struct {
+		// Clock of scheduler, system clock by default
+		Clock Clock
+		// MaxConcurrency limits count of parallel runs of all workers,
+		// ticks are dropped with ErrPoolBusy when all slots are busy,
+		// zero means no limit
+		MaxConcurrency int
+	}
+
+	// Scheduler runs workers by their schedules,
+	// each Scheduler has own set of workers.
+	Scheduler struct {
+		clock Clock
+		pool  *pool
+
+		mu      sync.Mutex
+		entries []*entry
+		changed chan struct{}
+		stop    chan struct{}
+		done    chan struct{}
+	}
+
+	// entry of scheduled worker
+	entry struct {
+		worker *worker
+		next   time.Time
+	}
+
+	systemClock struct{}
+)
+
+// Now returns current time
+func (systemClock) Now() time.Time { return time.Now() }
+
+// After waits for the duration to elapse
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// NewScheduler creates Scheduler
+func NewScheduler(opts SchedulerOptions) *Scheduler {
+	if opts.Clock == nil {
+		opts.Clock = systemClock{}
+	}
+
+	s := &Scheduler{
+		clock:   opts.Clock,
+		pool:    newPool(opts.Clock),
+		changed: make(chan struct{}, 1),
+	}
+	s.pool.setLimit(opts.MaxConcurrency)
+
+	return s
+}
+
+// SetMaxConcurrency limits count of parallel runs of all workers,
+// zero or negative n removes the limit.
+func (s *Scheduler) SetMaxConcurrency(n int) {
+	s.pool.setLimit(n)
+}
+
+// Dropped returns count of dropped ticks.
+func (s *Scheduler) Dropped() int64 {
+	return s.pool.dropped.Load()
+}
+
+// New returns an error if cannot create new worker
+func (s *Scheduler) New(opts Options) (err error) {
+	_, err = newWorker(opts, s.pool, func(w *worker, _ func(time.Time)) {
+		s.mu.Lock()
+		s.entries = append(s.entries, &entry{worker: w})
+		s.mu.Unlock()
+
+		s.notify()
+	})
+
+	return
+}
+
+// ElectLeader enables leader election mode: workers of all replicas
+// compete for the lock, and only the elected instance dispatches jobs.
+// Should be called before Start.
+func (s *Scheduler) ElectLeader(opts ElectionOptions) error {
+	e, err := newElection(opts, s.clock)
+	if err != nil {
+		return err
+	}
+
+	s.pool.elect(e)
+	return nil
+}
+
+// List returns status of all workers sorted by name.
+func (s *Scheduler) List() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list := make([]Status, 0, len(s.entries))
+	for _, e := range s.entries {
+		st := e.worker.status()
+		st.NextRun = e.next
+		list = append(list, st)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// Pause runs of the worker by schedule.
+func (s *Scheduler) Pause(name string) error {
+	w, err := s.find(name)
+	if err != nil {
+		return err
+	}
+
+	w.setPaused(true)
+	return nil
+}
+
+// Resume runs of the paused worker.
+func (s *Scheduler) Resume(name string) error {
+	w, err := s.find(name)
+	if err != nil {
+		return err
+	}
+
+	w.setPaused(false)
+	return nil
+}
+
+// Remove the worker, its active runs aren't interrupted.
+func (s *Scheduler) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, e := range s.entries {
+		if e.worker.options.Name == name {
+			s.entries = append(s.entries[:i], s.entries[i+1:]...)
+			s.pool.removeWorker(e.worker)
+			s.notify()
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+// TriggerNow runs the worker out of schedule, even if it's paused.
+// Returns ErrNotRunning when workers aren't started or the instance isn't a leader,
+// and ErrJobRunning or ErrPoolBusy when the run is dropped.
+func (s *Scheduler) TriggerNow(name string) error {
+	w, err := s.find(name)
+	if err != nil {
+		return err
+	}
+
+	return s.pool.dispatch(w, s.clock.Now())
+}
+
+// find the worker by name
+func (s *Scheduler) find(name string) (*worker, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		if e.worker.options.Name == name {
+			return e.worker, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// Start all workers.
+func (s *Scheduler) Start() {
+	s.pool.start()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != nil {
+		return
+	}
+
+	// schedules are calculated from the moment of start:
+	for _, e := range s.entries {
+		e.next = time.Time{}
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go s.run(s.stop, s.done)
+}
+
+// Stop all workers.
+func (s *Scheduler) Stop() {
+	s.pool.stop()
+
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Wait blocks until all workers will be stopped or ctx will be done,
+// nil ctx waits without deadline.
+func (s *Scheduler) Wait(ctx context.Context) error {
+	if ctx == nil {
+		s.pool.wait()
+		return nil
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.pool.wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Attach starts workers in Graceful, workers are stopped
+// and their jobs are finished when its context is cancelled.
+func (s *Scheduler) Attach(g graceful.Graceful) {
+	g.Go(func(ctx context.Context) error {
+		s.Start()
+		<-ctx.Done()
+		s.Stop()
+		return s.Wait(nil)
+	})
+}
+
+// notify loop about changed entries
+func (s *Scheduler) notify() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// due returns jobs to run at now with their scheduled ticks
+// and the time of the next activation
+func (s *Scheduler) due(now time.Time) (jobs []func(), next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		if e.next.IsZero() {
+			e.next = e.worker.options.Schedule.Next(now)
+		} else if !e.next.After(now) {
+			job, tick := e.worker.job, e.next
+			jobs = append(jobs, func() { job(tick) })
+			e.next = e.worker.options.Schedule.Next(now)
+		}
+
+		// zero time means that schedule has no activations:
+		if !e.next.IsZero() && (next.IsZero() || e.next.Before(next)) {
+			next = e.next
+		}
+	}
+
+	return
+}
+
+// run jobs by schedules until stop will be closed
+func (s *Scheduler) run(stop, done chan struct{}) {
+	defer close(done)
+
+	for {
+		now := s.clock.Now()
+		jobs, next := s.due(now)
+
+		for _, job := range jobs {
+			job()
+		}
+
+		var wake <-chan time.Time
+		if !next.IsZero() {
+			wake = s.clock.After(next.Sub(now))
+		}
+
+		select {
+		case <-wake:
+		case <-s.changed:
+		case <-stop:
+			return
+		}
+	}
+}
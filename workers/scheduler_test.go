@@ -0,0 +1,355 @@
+package workers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/graceful"
+	"github.com/cryptopay-dev/yaga/logger/nop"
+	"github.com/cryptopay-dev/yaga/web"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+)
+
+// dummySchedule runs every minute from any time
+type dummySchedule struct{}
+
+func (dummySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Minute)
+}
+
+// fakeClock is moved forward by test
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves clock and fires expired waiters
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
+
+// reset removes waiters, which are left by stopped schedulers
+func (c *fakeClock) reset() {
+	c.mu.Lock()
+	c.waiters = nil
+	c.mu.Unlock()
+}
+
+// waiting blocks until n waiters wait for the clock
+func (c *fakeClock) waiting(n int) bool {
+	limit := time.Now().Add(limitTimeForTest)
+
+	for time.Now().Before(limit) {
+		c.mu.Lock()
+		count := len(c.waiters)
+		c.mu.Unlock()
+
+		if count >= n {
+			return true
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	return false
+}
+
+func TestScheduler(t *testing.T) {
+	var (
+		count = atomic.NewInt32(0)
+		clock = newFakeClock()
+		s     = NewScheduler(SchedulerOptions{Clock: clock})
+	)
+
+	err := s.New(Options{
+		Name:     getUniqueWorkerName(),
+		Schedule: Every(time.Minute),
+		Handler:  func() { count.Inc() },
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	if err = s.New(Options{Name: "empty"}); !assert.Equal(t, ErrWrongOptions, err) {
+		t.FailNow()
+	}
+
+	s.Start()
+	defer s.Stop()
+
+	if !assert.True(t, clock.waiting(1), "Scheduler doesn't wait for clock") {
+		t.FailNow()
+	}
+
+	clock.Advance(30 * time.Second)
+	time.Sleep(10 * time.Millisecond)
+
+	if !assert.Equal(t, int32(0), count.Load(), "Worker runs before schedule") {
+		t.FailNow()
+	}
+
+	clock.Advance(30 * time.Second)
+
+	if !checkEqual(count, 1) {
+		assert.FailNow(t, "Worker doesn't run by schedule")
+	}
+
+	if !assert.True(t, clock.waiting(1), "Scheduler doesn't wait for clock") {
+		t.FailNow()
+	}
+
+	clock.Advance(time.Minute)
+
+	if !checkEqual(count, 2) {
+		assert.FailNow(t, "Worker doesn't run by schedule")
+	}
+}
+
+func TestSchedulerIsolation(t *testing.T) {
+	var (
+		first  = NewScheduler(SchedulerOptions{})
+		second = NewScheduler(SchedulerOptions{})
+		name   = getUniqueWorkerName()
+	)
+
+	assert.NoError(t, first.New(Options{Name: name, Schedule: dummySchedule{}, Handler: func() {}}))
+	assert.NoError(t, second.New(Options{Name: name, Schedule: dummySchedule{}, Handler: func() {}}))
+	assert.Equal(t, ErrAlreadyWorker, first.New(Options{Name: name, Schedule: dummySchedule{}, Handler: func() {}}))
+}
+
+func TestSchedulerContextHandler(t *testing.T) {
+	var (
+		clock   = newFakeClock()
+		s       = NewScheduler(SchedulerOptions{Clock: clock})
+		started = make(chan struct{}, 1)
+		release = make(chan struct{})
+		result  = make(chan error, 1)
+	)
+
+	err := s.New(Options{Name: "both", Schedule: dummySchedule{}, Handler: func() {},
+		ContextHandler: func(context.Context) error { return nil }})
+	if !assert.Equal(t, ErrWrongOptions, err) {
+		t.FailNow()
+	}
+
+	err = s.New(Options{
+		Name:     getUniqueWorkerName(),
+		Schedule: Every(time.Minute),
+		ContextHandler: func(ctx context.Context) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			<-release
+			result <- ctx.Err()
+			return ctx.Err()
+		},
+		Logger: nop.New(),
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	s.Start()
+
+	if !assert.True(t, clock.waiting(1), "Scheduler doesn't wait for clock") {
+		t.FailNow()
+	}
+
+	clock.Advance(time.Minute)
+
+	select {
+	case <-started:
+	case <-time.After(limitTimeForTest):
+		assert.FailNow(t, "Worker doesn't run by schedule")
+	}
+
+	s.Stop()
+
+	// handler is blocked, so Wait is stopped by deadline:
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if !assert.Equal(t, context.DeadlineExceeded, s.Wait(ctx)) {
+		t.FailNow()
+	}
+
+	close(release)
+
+	assert.Equal(t, context.Canceled, <-result)
+	assert.NoError(t, s.Wait(context.Background()))
+}
+
+func TestSchedulerAttach(t *testing.T) {
+	var (
+		clock = newFakeClock()
+		s     = NewScheduler(SchedulerOptions{Clock: clock})
+		g     = graceful.New(context.Background())
+		count = atomic.NewInt32(0)
+	)
+
+	err := s.New(Options{
+		Name:     getUniqueWorkerName(),
+		Schedule: Every(time.Minute),
+		ContextHandler: func(ctx context.Context) error {
+			count.Inc()
+			<-ctx.Done()
+			return nil
+		},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	s.Attach(g)
+
+	if !assert.True(t, clock.waiting(1), "Scheduler doesn't wait for clock") {
+		t.FailNow()
+	}
+
+	clock.Advance(time.Minute)
+
+	if !checkEqual(count, 1) {
+		assert.FailNow(t, "Worker doesn't run by schedule")
+	}
+
+	g.Cancel()
+	assert.NoError(t, g.Wait(nil))
+}
+
+func TestSchedulerControl(t *testing.T) {
+	var (
+		clock  = newFakeClock()
+		s      = NewScheduler(SchedulerOptions{Clock: clock})
+		first  = atomic.NewInt32(0)
+		second = atomic.NewInt32(0)
+	)
+
+	for name, count := range map[string]*atomic.Int32{"first": first, "second": second} {
+		count := count
+		err := s.New(Options{Name: name, Schedule: Every(time.Minute), Handler: func() { count.Inc() }})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	assert.Equal(t, ErrNotRunning, s.TriggerNow("first"))
+	assert.Equal(t, ErrNotFound, s.Pause("unknown"))
+
+	s.Start()
+	defer s.Stop()
+
+	if !assert.True(t, clock.waiting(1), "Scheduler doesn't wait for clock") {
+		t.FailNow()
+	}
+
+	list := s.List()
+	if !assert.Len(t, list, 2) {
+		t.FailNow()
+	}
+	assert.Equal(t, "first", list[0].Name)
+	assert.Equal(t, clock.Now().Add(time.Minute), list[0].NextRun)
+
+	assert.NoError(t, s.Pause("first"))
+	clock.Advance(time.Minute)
+
+	if !checkEqual(second, 1) {
+		assert.FailNow(t, "Worker doesn't run by schedule")
+	}
+	assert.Equal(t, int32(0), first.Load(), "Paused worker runs by schedule")
+
+	assert.NoError(t, s.TriggerNow("first"))
+	if !checkEqual(first, 1) {
+		assert.FailNow(t, "Worker doesn't run by trigger")
+	}
+
+	assert.NoError(t, s.Resume("first"))
+	assert.NoError(t, s.Remove("second"))
+	assert.Equal(t, ErrNotFound, s.Remove("second"))
+
+	list = s.List()
+	if !assert.Len(t, list, 1) {
+		t.FailNow()
+	}
+	assert.False(t, list[0].Paused)
+	assert.Equal(t, clock.Now(), list[0].LastRun, "Run isn't recorded by clock")
+}
+
+func TestSchedulerWrapGroup(t *testing.T) {
+	var (
+		e = web.New(web.Options{})
+		s = NewScheduler(SchedulerOptions{Clock: newFakeClock()})
+	)
+
+	err := s.New(Options{Name: "first", Schedule: dummySchedule{}, Handler: func() {}})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	s.WrapGroup(e.Group("/debug"))
+
+	cases := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{method: echo.GET, path: "/debug/workers/", code: http.StatusOK},
+		{method: echo.POST, path: "/debug/workers/first/pause/", code: http.StatusNoContent},
+		{method: echo.POST, path: "/debug/workers/first/resume/", code: http.StatusNoContent},
+		{method: echo.POST, path: "/debug/workers/first/trigger/", code: http.StatusConflict},
+		{method: echo.DELETE, path: "/debug/workers/first/", code: http.StatusNoContent},
+		{method: echo.DELETE, path: "/debug/workers/first/", code: http.StatusNotFound},
+	}
+
+	for _, item := range cases {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(item.method, item.path, nil))
+		assert.Equal(t, item.code, rec.Code, "%s %s", item.method, item.path)
+	}
+}
@@ -0,0 +1,42 @@
+package workers
+
+import (
+	"net/http"
+
+	"github.com/cryptopay-dev/yaga/web"
+)
+
+// WrapGroup adds routes to list and control workers of default scheduler to *echo.Group object.
+func WrapGroup(g *web.Group) {
+	defaultScheduler.WrapGroup(g)
+}
+
+// WrapGroup adds routes to list and control workers to *echo.Group object.
+func (s *Scheduler) WrapGroup(g *web.Group) {
+	g.GET("/workers/", s.listHandler())
+	g.POST("/workers/:name/pause/", s.controlHandler(s.Pause))
+	g.POST("/workers/:name/resume/", s.controlHandler(s.Resume))
+	g.POST("/workers/:name/trigger/", s.controlHandler(s.TriggerNow))
+	g.DELETE("/workers/:name/", s.controlHandler(s.Remove))
+}
+
+// listHandler responds with status of all workers
+func (s *Scheduler) listHandler() web.HandlerFunc {
+	return func(ctx web.Context) error {
+		return ctx.JSON(http.StatusOK, s.List())
+	}
+}
+
+// controlHandler calls action with name of the worker from path
+func (s *Scheduler) controlHandler(action func(name string) error) web.HandlerFunc {
+	return func(ctx web.Context) error {
+		switch err := action(ctx.Param("name")); err {
+		case nil:
+			return ctx.NoContent(http.StatusNoContent)
+		case ErrNotFound:
+			return web.NewHTTPError(http.StatusNotFound, err.Error())
+		default:
+			return web.NewHTTPError(http.StatusConflict, err.Error())
+		}
+	}
+}
@@ -1,25 +1,334 @@
 package workers
 
-type cronHandler func(*worker, func())
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cryptopay-dev/yaga/helpers/backoff"
+	"github.com/cryptopay-dev/yaga/locker"
+	"github.com/cryptopay-dev/yaga/tracer"
+	"github.com/getsentry/raven-go"
+	"github.com/robfig/cron"
+	"go.uber.org/atomic"
+)
+
+// DefaultRetryDelay between attempts of the worker run
+const DefaultRetryDelay = time.Second
+
+type cronHandler func(*worker, func(tick time.Time))
+
+// tickKey is a context key of the tick of the run
+type tickKey struct{}
+
+// withTick returns copy of ctx with the tick of the run
+func withTick(ctx context.Context, tick time.Time) context.Context {
+	return context.WithValue(ctx, tickKey{}, tick)
+}
+
+// tickFrom returns the tick of the run from ctx, zero when it's not set
+func tickFrom(ctx context.Context) time.Time {
+	tick, _ := ctx.Value(tickKey{}).(time.Time)
+	return tick
+}
 
 type worker struct {
-	job     func()
+	job     func(tick time.Time)
+	handler func(ctx context.Context) error
 	options Options
 	pool    *pool
+
+	// count of active runs, queued run flag and its tick
+	mu          sync.Mutex
+	active      int
+	pending     bool
+	pendingTick time.Time
+
+	// state and statistics of runs, guarded by mu
+	paused       bool
+	removed      bool
+	lastRun      time.Time
+	lastDuration time.Duration
+	lastErr      error
+
+	dropped *atomic.Int64
+}
+
+// Status of the worker
+type Status struct {
+	Name         string        `json:"name"`
+	Paused       bool          `json:"paused"`
+	Running      int           `json:"running"`
+	LastRun      time.Time     `json:"last_run"`
+	LastDuration time.Duration `json:"last_duration"`
+	LastError    string        `json:"last_error,omitempty"`
+	NextRun      time.Time     `json:"next_run"`
+	Dropped      int64         `json:"dropped"`
 }
 
 func newWorker(opts Options, p *pool, addToCron cronHandler) (*worker, error) {
-	if opts.Schedule == nil || opts.Handler == nil {
+	if opts.Schedule == nil || (opts.Handler == nil) == (opts.ContextHandler == nil) ||
+		opts.Overlap < OverlapAllow || opts.Overlap > OverlapQueue {
 		return nil, ErrWrongOptions
 	}
 
+	handler := opts.ContextHandler
+	if handler == nil {
+		handler = plainHandler(opts.Handler)
+	}
+
+	handler = recoverHandler(opts, handler)
+
+	if opts.MaxAttempts > 1 {
+		if opts.Backoff == nil {
+			opts.Backoff = backoff.Fixed(DefaultRetryDelay, 0)
+		}
+		handler = retryHandler(opts, p.clock, handler)
+	}
+
+	if opts.Locker != nil {
+		if opts.Logger == nil {
+			return nil, ErrWrongOptions
+		}
+		handler = lockedHandler(opts, handler)
+	}
+
 	w, err := p.createWorker(opts)
 	if err != nil {
 		return nil, err
 	}
 	w.pool = p
+	w.handler = handler
+	w.dropped = atomic.NewInt64(0)
 
 	addToCron(w, w.job)
 
 	return w, nil
 }
+
+// acquire the run for a tick by overlap policy, returns false
+// when the run is queued and an error when the tick is dropped
+func (w *worker) acquire(tick time.Time) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch {
+	case w.active == 0 || w.options.Overlap == OverlapAllow:
+		w.active++
+		return true, nil
+	case w.options.Overlap == OverlapQueue && !w.pending:
+		w.pending = true
+		w.pendingTick = tick
+		return false, nil
+	default:
+		return false, ErrJobRunning
+	}
+}
+
+// finish the run, returns true and tick of queued run when it should be started,
+// when next is false the queued run is discarded and true is returned
+// if it was queued
+func (w *worker) finish(next bool) (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	pending, tick := w.pending, w.pendingTick
+	w.pending, w.pendingTick = false, time.Time{}
+
+	if pending && next {
+		return tick, true
+	}
+
+	w.active--
+	return tick, pending
+}
+
+// run handler of the tick with ctx and queued runs after it in the slot
+// obtained by dispatch, errors are logged when Options.Logger is set
+func (w *worker) run(ctx context.Context, tick time.Time) {
+	defer w.pool.releaseSlot()
+
+	for {
+		started := w.pool.clock.Now()
+		err := w.handler(withTick(ctx, tick))
+		w.record(started, err)
+
+		if err != nil {
+			w.report(err)
+		}
+
+		// queued run isn't started, when workers are stopped:
+		next := ctx.Err() == nil
+		queuedTick, queued := w.finish(next)
+		if !queued {
+			return
+		} else if !next {
+			w.pool.drop(w, ctx.Err())
+			return
+		}
+
+		tick = queuedTick
+	}
+}
+
+// report error of the run to Options.Logger, errors are sent to Sentry
+// when it isn't set, except panics, which are already sent by recoverHandler
+func (w *worker) report(err error) {
+	if w.options.Logger != nil {
+		w.options.Logger.Errorf("Worker run failed: name=%s, err=%v", w.options.Name, err)
+		return
+	}
+
+	if _, ok := err.(*panicError); !ok {
+		raven.CaptureError(err, map[string]string{"worker": w.options.Name})
+	}
+}
+
+// record statistics of the run
+func (w *worker) record(started time.Time, err error) {
+	w.mu.Lock()
+	w.lastRun = started
+	w.lastDuration = w.pool.clock.Now().Sub(started)
+	w.lastErr = err
+	w.mu.Unlock()
+}
+
+// setPaused pauses or resumes runs by schedule
+func (w *worker) setPaused(paused bool) {
+	w.mu.Lock()
+	w.paused = paused
+	w.mu.Unlock()
+}
+
+func (w *worker) isPaused() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.paused
+}
+
+func (w *worker) remove() {
+	w.mu.Lock()
+	w.removed = true
+	w.mu.Unlock()
+}
+
+func (w *worker) isRemoved() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.removed
+}
+
+// status of the worker, next run is set by scheduler
+func (w *worker) status() Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	st := Status{
+		Name:         w.options.Name,
+		Paused:       w.paused,
+		Running:      w.active,
+		LastRun:      w.lastRun,
+		LastDuration: w.lastDuration,
+		Dropped:      w.dropped.Load(),
+	}
+
+	if w.lastErr != nil {
+		st.LastError = w.lastErr.Error()
+	}
+
+	return st
+}
+
+// plainHandler adapts handler without context
+func plainHandler(handler func()) func(ctx context.Context) error {
+	return func(context.Context) error {
+		handler()
+		return nil
+	}
+}
+
+// panicError is a recovered panic of handler
+type panicError struct {
+	value interface{}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("worker panic: %v", e.value)
+}
+
+// recoverHandler converts panic of handler to error,
+// which is logged and sent to Sentry
+func recoverHandler(opts Options, handler func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) (err error) {
+		defer func() {
+			if rVal := recover(); rVal != nil {
+				err = &panicError{value: rVal}
+
+				packet := tracer.StackPacket(err)
+				raven.Capture(packet, map[string]string{"worker": opts.Name})
+
+				if opts.Logger != nil {
+					opts.Logger.Errorf("Worker panic recovered: name=%s, panic=%v", opts.Name, rVal)
+				}
+			}
+		}()
+
+		return handler(ctx)
+	}
+}
+
+// retryHandler runs handler until it succeeds or Options.MaxAttempts
+// are exhausted, waiting by Options.Backoff of clock between attempts
+func retryHandler(opts Options, clock Clock, handler func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		for attempt := 1; ; attempt++ {
+			err := handler(ctx)
+			if err == nil || attempt >= opts.MaxAttempts || ctx.Err() != nil {
+				return err
+			}
+
+			if opts.Logger != nil {
+				opts.Logger.Warnf("Worker attempt failed: name=%s, attempt=%d, err=%v", opts.Name, attempt, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return err
+			case <-clock.After(opts.Backoff(attempt)):
+			}
+		}
+	}
+}
+
+// lockedHandler wraps handler in a lock keyed by worker name and scheduled tick
+// of the run, so replicas with the same schedule run handler once per tick.
+// Ticks of Every schedules depend on start of the replica, so they're truncated
+// by the delay. The lock isn't released after run and expires on the next tick,
+// run is skipped when the lock is held, other errors of Locker are returned.
+func lockedHandler(opts Options, handler func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		tick := tickFrom(ctx)
+		if every, ok := opts.Schedule.(cron.ConstantDelaySchedule); ok {
+			tick = tick.Truncate(every.Delay)
+		}
+
+		var (
+			key = fmt.Sprintf("workers:%s:%d", opts.Name, tick.Unix())
+			ttl = opts.Schedule.Next(tick).Sub(tick)
+		)
+
+		if ttl < time.Second {
+			ttl = time.Second
+		}
+
+		if _, err := locker.TryObtain(opts.Locker, key, ttl); err == locker.ErrNotObtained {
+			opts.Logger.Infof("Worker run skipped: name=%s, tick=%s, reason=%v", opts.Name, tick, err)
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		return handler(ctx)
+	}
+}
@@ -1,9 +1,14 @@
 package workers
 
 import (
+	"context"
 	"errors"
 	"time"
 
+	"github.com/cryptopay-dev/yaga/graceful"
+	"github.com/cryptopay-dev/yaga/helpers/backoff"
+	"github.com/cryptopay-dev/yaga/locker"
+	"github.com/cryptopay-dev/yaga/logger"
 	"github.com/robfig/cron"
 )
 
@@ -13,8 +18,29 @@ type (
 		Name     string
 		Schedule Schedule
 		Handler  func()
+		// ContextHandler is used instead of Handler, its context
+		// is cancelled when workers are stopped
+		ContextHandler func(ctx context.Context) error
+		// Overlap policy of runs, by default runs are allowed to overlap
+		Overlap OverlapPolicy
+		// MaxAttempts of the run, failed or panicked run is retried
+		// until attempts are exhausted, zero means a single attempt
+		MaxAttempts int
+		// Backoff between attempts, DefaultRetryDelay by default
+		Backoff backoff.Backoff
+		// Locker, when set, runs Handler at most once per scheduled tick across replicas,
+		// its locks must expire by ttl, so Postgres advisory locks aren't supported
+		Locker locker.Locker
+		// Logger of skipped runs, dropped ticks, panics and errors of ContextHandler,
+		// errors are sent to Sentry when it isn't set,
+		// required when Locker is set
+		Logger logger.Logger
 	}
 
+	// OverlapPolicy describes what happens with a tick,
+	// when previous run of the worker isn't finished yet.
+	OverlapPolicy int
+
 	// Schedule describes a job's duty cycle.
 	//
 	// Return the next activation time, later than the given time.
@@ -22,26 +48,49 @@ type (
 	Schedule = cron.Schedule
 )
 
+const (
+	// OverlapAllow runs the worker in parallel with its previous run
+	OverlapAllow OverlapPolicy = iota
+	// OverlapSkip drops the tick
+	OverlapSkip
+	// OverlapQueue runs the worker once again after previous run,
+	// only one run is queued, following ticks are dropped
+	OverlapQueue
+)
+
 var (
 	// ErrAlreadyWorker is returned by New calls
 	// when workers name is already exists.
 	ErrAlreadyWorker = errors.New("worker name must be unique")
 
 	// ErrWrongOptions is returned by New calls
-	// when parameter Options.Schedule is NIL or Options.Handler is NIL.
+	// when parameter Options.Schedule is NIL, or both or none of
+	// Options.Handler and Options.ContextHandler are set,
+	// or Options.Logger is NIL when Options.Locker is set.
 	ErrWrongOptions = errors.New("wrong options")
 
-	cronWorker = cron.New()
-	poolWorker = newPool()
+	// ErrJobRunning is a reason of dropped tick,
+	// when previous run of the worker isn't finished yet.
+	ErrJobRunning = errors.New("previous run is not finished")
+
+	// ErrPoolBusy is a reason of dropped tick,
+	// when workers pool cannot accept more jobs
+	// or all slots of MaxConcurrency are busy.
+	ErrPoolBusy = errors.New("workers pool is busy")
+
+	// ErrNotRunning is returned by TriggerNow calls,
+	// when workers aren't started or the instance isn't a leader.
+	ErrNotRunning = errors.New("workers are not running")
+
+	// ErrNotFound is returned when worker with the name isn't found.
+	ErrNotFound = errors.New("worker not found")
+
+	defaultScheduler = NewScheduler(SchedulerOptions{})
 )
 
 // New returns an error if cannot create new worker
-func New(opts Options) (err error) {
-	_, err = newWorker(opts, poolWorker, func(w *worker, handler func()) {
-		cronWorker.Schedule(w.options.Schedule, cron.FuncJob(handler))
-	})
-
-	return
+func New(opts Options) error {
+	return defaultScheduler.New(opts)
 }
 
 // Parse returns a new crontab schedule representing the given spec.
@@ -61,19 +110,66 @@ func Every(duration time.Duration) Schedule {
 	return cron.Every(duration)
 }
 
+// ElectLeader enables leader election mode for default scheduler.
+// Should be called before Start.
+func ElectLeader(opts ElectionOptions) error {
+	return defaultScheduler.ElectLeader(opts)
+}
+
+// SetMaxConcurrency limits count of parallel runs of default scheduler,
+// zero or negative n removes the limit.
+func SetMaxConcurrency(n int) {
+	defaultScheduler.SetMaxConcurrency(n)
+}
+
+// Dropped returns count of dropped ticks of default scheduler.
+func Dropped() int64 {
+	return defaultScheduler.Dropped()
+}
+
+// List returns status of all workers of default scheduler.
+func List() []Status {
+	return defaultScheduler.List()
+}
+
+// Pause runs of the worker by schedule.
+func Pause(name string) error {
+	return defaultScheduler.Pause(name)
+}
+
+// Resume runs of the paused worker.
+func Resume(name string) error {
+	return defaultScheduler.Resume(name)
+}
+
+// Remove the worker, its active runs aren't interrupted.
+func Remove(name string) error {
+	return defaultScheduler.Remove(name)
+}
+
+// TriggerNow runs the worker out of schedule.
+func TriggerNow(name string) error {
+	return defaultScheduler.TriggerNow(name)
+}
+
 // Start all workers.
 func Start() {
-	poolWorker.start()
-	cronWorker.Start()
+	defaultScheduler.Start()
 }
 
 // Stop all workers.
 func Stop() {
-	poolWorker.stop()
-	cronWorker.Stop()
+	defaultScheduler.Stop()
+}
+
+// Wait blocks until all workers will be stopped or ctx will be done,
+// nil ctx waits without deadline.
+func Wait(ctx context.Context) error {
+	return defaultScheduler.Wait(ctx)
 }
 
-// Wait blocks until all workers will be stopped.
-func Wait() {
-	poolWorker.wait()
+// Attach starts workers of default scheduler in Graceful,
+// workers are stopped when its context is cancelled.
+func Attach(g graceful.Graceful) {
+	defaultScheduler.Attach(g)
 }
@@ -13,7 +13,6 @@ import (
 
 var (
 	limitTimeForTest = time.Second * 5
-	minTickForTest   = time.Microsecond * 10
 	uniqWorkerN      = atomic.NewInt32(0)
 )
 
@@ -21,11 +20,11 @@ func getUniqueWorkerName() string {
 	return fmt.Sprintf("worker %d", uniqWorkerN.Inc())
 }
 
-func checkGtZero(cnt *atomic.Int32) bool {
+func checkEqual(cnt *atomic.Int32, expected int32) bool {
 	limit := time.Now().Add(limitTimeForTest)
 
 	for {
-		if cnt.Load() > 0 {
+		if cnt.Load() == expected {
 			return true
 		}
 
@@ -37,141 +36,181 @@ func checkGtZero(cnt *atomic.Int32) bool {
 	}
 }
 
-func checkEqual(cnt *atomic.Int32, expected int32) bool {
-	limit := time.Now().Add(limitTimeForTest)
+// schedulerForTest is a Scheduler with fake clock,
+// its workers run every minute of the clock
+type schedulerForTest struct {
+	*Scheduler
+	clock *fakeClock
+}
 
-	for {
-		if cnt.Load() == expected {
-			return true
-		}
+func newSchedulerForTest() *schedulerForTest {
+	clock := newFakeClock()
 
-		if time.Now().After(limit) {
+	return &schedulerForTest{
+		Scheduler: NewScheduler(SchedulerOptions{Clock: clock}),
+		clock:     clock,
+	}
+}
+
+// create the worker, which runs handler every minute
+func (s *schedulerForTest) create(name string, handler func()) error {
+	return s.New(Options{
+		Name:     name,
+		Schedule: Every(time.Minute),
+		Handler:  handler,
+	})
+}
+
+// tick moves clock to the next minute, when scheduler waits for it
+func (s *schedulerForTest) tick() bool {
+	if !s.clock.waiting(1) {
+		return false
+	}
+
+	s.clock.Advance(time.Minute)
+	return true
+}
+
+// tickUntil moves clock by minutes until cond is true
+func (s *schedulerForTest) tickUntil(cond func() bool) bool {
+	limit := time.Now().Add(limitTimeForTest)
+
+	for time.Now().Before(limit) {
+		if !s.tick() {
 			return false
 		}
 
-		runtime.Gosched()
+		// runs of the tick are asynchronous:
+		for wait := time.Now().Add(10 * time.Millisecond); time.Now().Before(wait); runtime.Gosched() {
+			if cond() {
+				return true
+			}
+		}
 	}
+
+	return false
+}
+
+// stop workers and wait for their runs,
+// moving clock doesn't run them anymore
+func (s *schedulerForTest) stop() {
+	s.Stop()
+	s.Wait(nil)
+	s.clock.reset()
+}
+
+// stopped reports whether cnt isn't changed by a tick after stop
+func (s *schedulerForTest) stopped(cnt *atomic.Int32) bool {
+	before := cnt.Load()
+
+	s.clock.Advance(time.Minute)
+	time.Sleep(10 * time.Millisecond)
+
+	return cnt.Load() == before
 }
 
 func TestWorkerConflictName(t *testing.T) {
-	c, creater := newCronForTest()
-	defer c.StopCron()
+	s := newSchedulerForTest()
 
 	name := getUniqueWorkerName()
-	w, err := creater(name, minTickForTest, func() {})
-	if !assert.NoError(t, err) || !assert.NotNil(t, w) {
+	if err := s.create(name, func() {}); !assert.NoError(t, err) {
 		assert.FailNow(t, "Cannot create worker")
 	}
-	if !assert.Equal(t, name, w.options.Name) || !assert.Equal(t, 1, len(w.pool.workers)) {
+	if !assert.Equal(t, 1, len(s.pool.workers)) {
 		assert.FailNow(t, "Invalidate workers data, must be 1 worker")
 	}
 
 	// create new worker with existing name
-	w, err = creater(name, minTickForTest, func() {})
-	if !assert.Error(t, err) || !assert.Nil(t, w) {
+	if err := s.create(name, func() {}); !assert.Equal(t, ErrAlreadyWorker, err) {
 		assert.FailNow(t, "Created new worker with duplicate name")
 	}
 
 	// create new worker with unique name
-	w, err = creater(name+" foobar", minTickForTest, func() {})
-	if !assert.NoError(t, err) || !assert.NotNil(t, w) {
+	if err := s.create(name+" foobar", func() {}); !assert.NoError(t, err) {
 		assert.FailNow(t, "Cannot create worker with unique name")
 	}
 
-	if !assert.Equal(t, 2, len(w.pool.workers)) {
+	if !assert.Equal(t, 2, len(s.pool.workers)) {
 		assert.FailNow(t, "Invalidate workers data, must be 2 workers")
 	}
 }
 
 func TestWorkerStartAndStop(t *testing.T) {
-	c, creater := newCronForTest()
-	defer c.StopCron()
-	c.Start()
+	var (
+		s    = newSchedulerForTest()
+		info = atomic.NewInt32(0)
+	)
 
-	t.Run("worker should be start", func(t *testing.T) {
-		start := atomic.NewInt32(0)
+	err := s.create(getUniqueWorkerName(), func() {
+		info.Inc()
+	})
+	if !assert.NoError(t, err, "Cannot create worker") {
+		t.FailNow()
+	}
 
-		_, err := creater(getUniqueWorkerName(), minTickForTest, func() {
-			start.Inc()
-		})
-		if !assert.NoError(t, err, "Cannot create worker") {
-			t.FailNow()
-		}
+	s.Start()
+	defer s.Stop()
 
-		if !checkGtZero(start) {
+	t.Run("worker should be start", func(t *testing.T) {
+		if !s.tickUntil(func() bool { return info.Load() > 0 }) {
 			assert.FailNow(t, "Cannot start worker")
 		}
 	})
 
 	t.Run("worker should be stop", func(t *testing.T) {
-		info := atomic.NewInt32(0)
-
-		_, err := creater(getUniqueWorkerName(), minTickForTest, func() {
-			info.Inc()
-		})
-		if !assert.NoError(t, err, "Cannot create worker") {
-			t.FailNow()
-		}
-
-		if !checkGtZero(info) {
-			assert.FailNow(t, "Cannot start worker")
-		}
-
-		c.Stop()
-
-		c.Wait()
+		s.stop()
 		info.Store(312)
-		time.Sleep(minTickForTest * 100)
 
-		if !checkEqual(info, 312) {
+		if !assert.True(t, s.stopped(info)) || !checkEqual(info, 312) {
 			assert.FailNow(t, "Cannot stop worker")
 		}
 	})
 }
 
 func TestWorkersRestart(t *testing.T) {
-	c, creater := newCronForTest()
-	defer c.StopCron()
-	c.Start()
-
 	t.Run("worker should be restart", func(t *testing.T) {
 		var (
+			s          = newSchedulerForTest()
 			info       = atomic.NewInt32(0)
 			num  int32 = 321
 		)
 
-		_, err := creater(getUniqueWorkerName(), minTickForTest, func() {
+		err := s.create(getUniqueWorkerName(), func() {
 			info.Store(num)
 		})
 		if !assert.NoError(t, err, "Cannot create worker") {
 			t.FailNow()
 		}
 
-		if !checkEqual(info, 321) {
+		s.Start()
+		defer s.Stop()
+
+		if !s.tickUntil(func() bool { return info.Load() == 321 }) {
 			assert.FailNow(t, "Cannot start worker")
 		}
-		c.Stop()
 
-		c.Wait()
+		s.stop()
 		info.Store(1122)
-		time.Sleep(minTickForTest * 100)
 
-		if !checkEqual(info, 1122) {
+		if !assert.True(t, s.stopped(info)) {
 			assert.FailNow(t, "Cannot stop worker")
 		}
 
 		num = 246975
-		c.Start()
+		s.Start()
 
-		if !checkEqual(info, num) {
+		if !s.tickUntil(func() bool { return info.Load() == num }) {
 			assert.FailNow(t, "Cannot restart worker")
 		}
 	})
 
 	t.Run("workers should be restart", func(t *testing.T) {
-		info := atomic.NewInt32(0)
+		var (
+			s    = newSchedulerForTest()
+			info = atomic.NewInt32(0)
+		)
 
-		_, err := creater(getUniqueWorkerName(), minTickForTest, func() {
+		err := s.create(getUniqueWorkerName(), func() {
 			info.CAS(0, 11)
 			info.CAS(456, 789)
 		})
@@ -179,7 +218,7 @@ func TestWorkersRestart(t *testing.T) {
 			t.FailNow()
 		}
 
-		_, err = creater(getUniqueWorkerName(), minTickForTest, func() {
+		err = s.create(getUniqueWorkerName(), func() {
 			info.CAS(11, 22)
 			info.CAS(123, 456)
 		})
@@ -187,37 +226,34 @@ func TestWorkersRestart(t *testing.T) {
 			t.FailNow()
 		}
 
-		if !checkEqual(info, 22) {
+		s.Start()
+		defer s.Stop()
+
+		if !s.tickUntil(func() bool { return info.Load() == 22 }) {
 			assert.FailNow(t, "Cannot start workers")
 		}
 
-		c.Stop()
-
-		c.Wait()
+		s.stop()
 		info.Store(123)
-		time.Sleep(minTickForTest * 100)
 
-		if !checkEqual(info, 123) {
+		if !assert.True(t, s.stopped(info)) {
 			assert.FailNow(t, "Cannot stop workers")
 		}
 
-		c.Start()
+		s.Start()
 
-		if !checkEqual(info, 789) {
+		if !s.tickUntil(func() bool { return info.Load() == 789 }) {
 			assert.FailNow(t, "Cannot start workers")
 		}
 	})
 }
 
 func TestWorkersWait(t *testing.T) {
-	c, creater := newCronForTest()
-	defer c.StopCron()
-	c.Start()
-
 	t.Run("workers should be wait while one worker locked", func(t *testing.T) {
 		var (
 			err error
 			mu  sync.Mutex
+			s   = newSchedulerForTest()
 
 			watch = make(chan struct{})
 			info  = atomic.NewInt32(0)
@@ -231,7 +267,7 @@ func TestWorkersWait(t *testing.T) {
 				lockedFlag.Store(true)
 			}
 			n := int32(i)
-			_, err = creater(getUniqueWorkerName(), minTickForTest, func() {
+			err = s.create(getUniqueWorkerName(), func() {
 				info.CAS(n, n+1)
 				if lockedFlag.Load() {
 					lockedFlag.Store(false)
@@ -243,19 +279,21 @@ func TestWorkersWait(t *testing.T) {
 			}
 		}
 
-		if !checkEqual(info, 5) {
+		s.Start()
+
+		if !s.tickUntil(func() bool { return info.Load() == 5 }) {
 			assert.FailNow(t, "Cannot start workers")
 		}
 
-		c.Stop()
+		s.Stop()
 
 		go func() {
-			c.Wait()
+			s.Wait(nil)
 			close(watch)
 		}()
 
 		select {
-		case <-time.After(minTickForTest * 100):
+		case <-time.After(10 * time.Millisecond):
 		case <-watch:
 			assert.FailNow(t, "Fail waiting of workers")
 		}
@@ -266,21 +304,18 @@ func TestWorkersWait(t *testing.T) {
 }
 
 func TestWorkersStop(t *testing.T) {
-	c, creater := newCronForTest()
-	defer c.StopCron()
-	c.Start()
-
 	t.Run("all workers should be closed", func(t *testing.T) {
 		var (
 			err error
 			num int32 = 2
+			s         = newSchedulerForTest()
 
 			info = atomic.NewInt32(num)
 		)
 
 		for i := 0; i < 5; i++ {
 			n := num
-			_, err = creater(getUniqueWorkerName(), minTickForTest, func() {
+			err = s.create(getUniqueWorkerName(), func() {
 				info.CAS(n, n*2)
 				info.CAS(123, 75)
 			})
@@ -291,17 +326,17 @@ func TestWorkersStop(t *testing.T) {
 			num = num * 2
 		}
 
-		if !checkEqual(info, num) {
+		s.Start()
+		defer s.Stop()
+
+		if !s.tickUntil(func() bool { return info.Load() == num }) {
 			assert.FailNow(t, "Cannot start workers")
 		}
 
-		c.Stop()
-
-		c.Wait()
+		s.stop()
 		info.Store(123)
-		time.Sleep(minTickForTest * 100)
 
-		if !checkEqual(info, 123) {
+		if !assert.True(t, s.stopped(info)) || !checkEqual(info, 123) {
 			assert.FailNow(t, "Cannot stop workers")
 		}
 	})